@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// rollbackTimeout bounds the compensation of a failed job template, which may run after the template timeout expired.
+const rollbackTimeout = 30 * time.Second
+
+// exitRollbackIncomplete is the exit status of an apply that could not roll back a failed job template.
+const exitRollbackIncomplete = 2
+
+// errRollbackIncomplete marks failures that left SSM parameters or job templates in a partially applied state.
+var errRollbackIncomplete = errors.New("rollback incomplete")
+
+// applyOptions controls where the job template IDs are published and how a failed job template is rolled back.
+type applyOptions struct {
+	// DeleteOnRollback deletes the newly created job template when its SSM parameters are rolled back.
+	DeleteOnRollback bool
+	// Outputs builds the sinks of the job template outputs, none when nil.
+	Outputs *outputSinks
+	// VirtualClusterID is the virtual cluster of smoke tests that do not name one.
+	VirtualClusterID string
+}
+
+// templateResult is the outcome of processing a single job template.
+type templateResult struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// runPool calls fn for every job template using at most concurrency workers.
+// Each call gets the timeout returned for its template, derived from ctx, a failing template
+// does not stop the others, and the results are returned in configuration order.
+func runPool(ctx context.Context, jobTemplates []template.JobTemplateConfig, concurrency int, timeout func(template.JobTemplateConfig) time.Duration,
+	fn func(ctx context.Context, jobTemplate template.JobTemplateConfig) error,
+) []templateResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]templateResult, len(jobTemplates))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < min(concurrency, len(jobTemplates)); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				templateCtx, cancel := context.WithTimeout(ctx, timeout(jobTemplates[i]))
+				start := time.Now()
+				err := fn(templateCtx, jobTemplates[i])
+				cancel()
+				results[i] = templateResult{Name: jobTemplates[i].Name, Err: err, Duration: time.Since(start)}
+			}
+		}()
+	}
+
+	for i := range jobTemplates {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// runApply creates every job template in parallel and reports the failures once all templates are done.
+func runApply(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, jobTemplates []template.JobTemplateConfig, cfg Config,
+	clientTokenGenerator awsutils.ClientTokenGenerator, w io.Writer,
+) error {
+	opts := applyOptions{DeleteOnRollback: cfg.RollbackDelete, Outputs: newOutputSinks(w), VirtualClusterID: cfg.VirtualClusterID}
+	// A smoke test extends the time allowed for its template.
+	timeout := func(jobTemplate template.JobTemplateConfig) time.Duration {
+		return cfg.TemplateTimeout + smokeTestTimeout(jobTemplate)
+	}
+	results := runPool(ctx, jobTemplates, cfg.Concurrency, timeout, func(ctx context.Context, jobTemplate template.JobTemplateConfig) error {
+		return processJobTemplate(ctx, logger, clients, jobTemplate, clientTokenGenerator, opts)
+	})
+
+	failed, incomplete := 0, 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			if errors.Is(result.Err, errRollbackIncomplete) {
+				incomplete++
+			}
+			logger.Errorf("Job template '%s' failed after %s: %v", result.Name, result.Duration.Round(time.Millisecond), result.Err)
+
+			continue
+		}
+		logger.Infof("Job template '%s' done in %s", result.Name, result.Duration.Round(time.Millisecond))
+	}
+
+	if incomplete > 0 {
+
+		return fmt.Errorf("%d of %d job templates failed, %d could not be rolled back: %w", failed, len(results), incomplete, errRollbackIncomplete)
+	}
+	if failed > 0 {
+
+		return fmt.Errorf("%d of %d job templates failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// rollbackJobTemplate restores the SSM parameters written for a failed job template and optionally
+// deletes the template created for it. The returned error wraps cause and lists what was rolled back.
+func rollbackJobTemplate(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, name, jobTemplateID string,
+	ssmTransaction *awsutils.SSMTransaction, opts applyOptions, cause error,
+) error {
+	logger.Errorf("Job template '%s' failed, rolling back: %v", name, cause)
+
+	// The template context may be the reason of the failure, so compensation gets its own deadline.
+	rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	rolledBack, err := ssmTransaction.Rollback(rollbackCtx)
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// Never delete a template that one of the parameters pointed to before this run.
+	if opts.DeleteOnRollback && !pointsTo(ssmTransaction.PreviousValues(), jobTemplateID) {
+		if err := awsutils.DeleteJobTemplate(rollbackCtx, clients.EMRContainers, jobTemplateID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete job template %s: %w", jobTemplateID, err))
+		} else {
+			rolledBack = append(rolledBack, "deleted job template "+jobTemplateID)
+		}
+	}
+
+	for _, action := range rolledBack {
+		logger.Warnf("Rolled back job template '%s': %s", name, action)
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			logger.Errorf("Rollback of job template '%s' failed: %v", name, err)
+		}
+
+		return fmt.Errorf("%w; %w of job template '%s': %w", cause, errRollbackIncomplete, name, errors.Join(errs...))
+	}
+
+	summary := "nothing to roll back"
+	if len(rolledBack) > 0 {
+		summary = strings.Join(rolledBack, ", ")
+	}
+
+	return fmt.Errorf("%w; rolled back: %s", cause, summary)
+}
+
+// publishTemplateID publishes the job template ID to every sink in order and stops at the first failure.
+// It returns the sinks that were published before the failure.
+func publishTemplateID(ctx context.Context, logger *logrus.Logger, sinks []awsutils.TemplateIDSink, name, jobTemplateID string) ([]string, error) {
+	var published []string
+	for _, sink := range sinks {
+		if err := sink.Publish(ctx, name, jobTemplateID); err != nil {
+
+			return published, fmt.Errorf("failed to publish job template '%s' to %s: %w", name, sink, err)
+		}
+		logger.Infof("Published job template '%s' ID %s to %s", name, jobTemplateID, sink)
+		published = append(published, sink.String())
+	}
+
+	return published, nil
+}
+
+// pointsTo reports whether any of the parameter values is the given job template ID.
+func pointsTo(values map[string]string, jobTemplateID string) bool {
+	for _, value := range values {
+		if value == jobTemplateID {
+			return true
+		}
+	}
+
+	return false
+}
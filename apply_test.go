@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// applyJobTemplate processes a job template and requires it to succeed.
+func applyJobTemplate(t *testing.T, fake *fakeAWS, jobTemplate template.JobTemplateConfig) {
+	t.Helper()
+	require.NoError(t, processJobTemplate(context.Background(), testLogger(), fake.clients(), jobTemplate, &awsutils.RealClientTokenGenerator{}, applyOptions{}))
+}
+
+func TestProcessJobTemplate_RestoresSSMParametersOnPartialFailure(t *testing.T) {
+	t.Parallel()
+	fake := newFakeAWS(map[string]string{"/emr/a": "jt-old", "/emr/c": "jt-other"})
+	fake.FailPut = map[string]error{"/emr/c": errors.New("access denied")}
+
+	err := processJobTemplate(context.Background(), testLogger(), fake.clients(), testJobTemplate("etl", "/emr/a", "/emr/b", "/emr/c"),
+		&awsutils.RealClientTokenGenerator{}, applyOptions{})
+
+	require.ErrorContains(t, err, "failed to update SSM parameter '/emr/c'")
+	assert.ErrorContains(t, err, "rolled back: deleted SSM parameter '/emr/b', restored SSM parameter '/emr/a' to jt-old")
+	assert.NotErrorIs(t, err, errRollbackIncomplete)
+	assert.Equal(t, map[string]string{"/emr/a": "jt-old", "/emr/c": "jt-other"}, fake.Values)
+	assert.Equal(t, []string{"jt-1"}, fake.templateIDs())
+}
+
+func TestProcessJobTemplate_DeleteOnRollback(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		previous    string
+		wantDeleted []string
+		wantIDs     []string
+	}{
+		{
+			name:        "New Template",
+			previous:    "jt-other",
+			wantDeleted: []string{"jt-1"},
+			wantIDs:     []string{},
+		},
+		{
+			// The client token returns the template a parameter already points to, it must survive.
+			name:     "Template Pointed To Before",
+			previous: "jt-1",
+			wantIDs:  []string{"jt-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fake := newFakeAWS(nil)
+			clients := fake.clients()
+			opts := applyOptions{DeleteOnRollback: true}
+
+			// A first apply creates jt-1 for /emr/b only.
+			require.NoError(t, processJobTemplate(context.Background(), testLogger(), clients, testJobTemplate("etl", "/emr/b"), &awsutils.RealClientTokenGenerator{}, opts))
+			fake.Values["/emr/a"] = "jt-old"
+			fake.Values["/emr/b"] = tt.previous
+			fake.FailPut = map[string]error{"/emr/c": errors.New("access denied")}
+
+			err := processJobTemplate(context.Background(), testLogger(), clients, testJobTemplate("etl", "/emr/a", "/emr/b", "/emr/c"), &awsutils.RealClientTokenGenerator{}, opts)
+
+			require.ErrorContains(t, err, "failed to update SSM parameter '/emr/c'")
+			assert.Equal(t, tt.wantDeleted, fake.Deleted)
+			assert.Equal(t, tt.wantIDs, fake.templateIDs())
+			assert.Equal(t, map[string]string{"/emr/a": "jt-old", "/emr/b": tt.previous}, fake.Values)
+		})
+	}
+}
+
+func TestProcessJobTemplate_Unchanged(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		setup      func(t *testing.T, fake *fakeAWS)
+		apply      template.JobTemplateConfig
+		wantIDs    []string
+		wantValues map[string]string
+	}{
+		{
+			name: "Same Content",
+			setup: func(t *testing.T, fake *fakeAWS) {
+				t.Helper()
+				applyJobTemplate(t, fake, testJobTemplate("etl", "/emr/a"))
+			},
+			apply:      testJobTemplate("etl", "/emr/a"),
+			wantIDs:    []string{"jt-1"},
+			wantValues: map[string]string{"/emr/a": "jt-1"},
+		},
+		{
+			name: "Found By Name Tag",
+			setup: func(t *testing.T, fake *fakeAWS) {
+				t.Helper()
+				applyJobTemplate(t, fake, testJobTemplate("etl"))
+			},
+			apply:      testJobTemplate("etl"),
+			wantIDs:    []string{"jt-1"},
+			wantValues: map[string]string{},
+		},
+		{
+			name: "Without Fingerprint Tag",
+			setup: func(t *testing.T, fake *fakeAWS) {
+				t.Helper()
+				fake.addTemplate("jt-manual", "etl", preparedJobTemplateData(t, testJobTemplate("etl")), nil)
+				fake.Values["/emr/a"] = "jt-manual"
+			},
+			apply:      testJobTemplate("etl", "/emr/a"),
+			wantIDs:    []string{"jt-manual"},
+			wantValues: map[string]string{"/emr/a": "jt-manual"},
+		},
+		{
+			// The fingerprint tag decides without comparing fields, the stored data is never read.
+			name: "Fingerprint Tag Matches",
+			setup: func(t *testing.T, fake *fakeAWS) {
+				t.Helper()
+				input, err := awsutils.PrepareJobTemplateInput(testJobTemplate("etl", "/emr/a"), &awsutils.RealParameterConfigurator{}, &awsutils.RealSparkSubmitCommandBuilder{}, &awsutils.RealClientTokenGenerator{})
+				require.NoError(t, err)
+				fake.addTemplate("jt-tagged", "etl", nil, map[string]string{awsutils.FingerprintTag: awsutils.JobTemplateFingerprint(input)})
+				fake.Values["/emr/a"] = "jt-tagged"
+			},
+			apply:      testJobTemplate("etl", "/emr/a"),
+			wantIDs:    []string{"jt-tagged"},
+			wantValues: map[string]string{"/emr/a": "jt-tagged"},
+		},
+		{
+			// A stale fingerprint falls back to the field diff.
+			name: "Fingerprint Tag Differs",
+			setup: func(t *testing.T, fake *fakeAWS) {
+				t.Helper()
+				fake.addTemplate("jt-stale", "etl", preparedJobTemplateData(t, testJobTemplate("etl")), map[string]string{awsutils.FingerprintTag: "stale"})
+				fake.Values["/emr/a"] = "jt-stale"
+			},
+			apply:      testJobTemplate("etl", "/emr/a"),
+			wantIDs:    []string{"jt-stale"},
+			wantValues: map[string]string{"/emr/a": "jt-stale"},
+		},
+		{
+			name: "Parameter Added",
+			setup: func(t *testing.T, fake *fakeAWS) {
+				t.Helper()
+				applyJobTemplate(t, fake, testJobTemplate("etl", "/emr/a"))
+			},
+			apply:      testJobTemplate("etl", "/emr/a", "/emr/b"),
+			wantIDs:    []string{"jt-1"},
+			wantValues: map[string]string{"/emr/a": "jt-1", "/emr/b": "jt-1"},
+		},
+		{
+			name: "Content Changed",
+			setup: func(t *testing.T, fake *fakeAWS) {
+				t.Helper()
+				applyJobTemplate(t, fake, testJobTemplate("etl", "/emr/a"))
+			},
+			apply: func() template.JobTemplateConfig {
+				jobTemplate := testJobTemplate("etl", "/emr/a")
+				jobTemplate.ReleaseLabel = "emr-7.0.0-latest"
+
+				return jobTemplate
+			}(),
+			wantIDs:    []string{"jt-1", "jt-2"},
+			wantValues: map[string]string{"/emr/a": "jt-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fake := newFakeAWS(nil)
+			tt.setup(t, fake)
+
+			applyJobTemplate(t, fake, tt.apply)
+
+			assert.Equal(t, tt.wantIDs, fake.templateIDs())
+			assert.Equal(t, tt.wantValues, fake.Values)
+			// Unchanged parameters get no new version.
+			for name, history := range fake.History {
+				assert.Len(t, history, len(slices.Compact(slices.Clone(history))), name)
+			}
+		})
+	}
+}
+
+func TestRunApply_RollbackIncomplete(t *testing.T) {
+	t.Parallel()
+	fake := newFakeAWS(map[string]string{"/emr/a": "jt-old"})
+	fake.FailPut = map[string]error{"/emr/b": errors.New("access denied")}
+	fake.FailDelete = map[string]error{"jt-1": errors.New("throttled")}
+	cfg := Config{Concurrency: 2, TemplateTimeout: time.Minute, RollbackDelete: true}
+	jobTemplates := []template.JobTemplateConfig{testJobTemplate("etl", "/emr/a", "/emr/b"), testJobTemplate("report", "/emr/report")}
+
+	err := runApply(context.Background(), testLogger(), fake.clients(), jobTemplates, cfg, &awsutils.RealClientTokenGenerator{}, io.Discard)
+
+	require.ErrorIs(t, err, errRollbackIncomplete)
+	assert.EqualError(t, err, "1 of 2 job templates failed, 1 could not be rolled back: rollback incomplete")
+	assert.Equal(t, exitRollbackIncomplete, exitStatus(err))
+	assert.Equal(t, "jt-old", fake.Values["/emr/a"])
+}
+
+func TestExitStatus(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 1, exitStatus(errors.New("2 of 2 job templates failed")))
+	assert.Equal(t, 2, exitStatus(errRollbackIncomplete))
+}
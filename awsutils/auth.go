@@ -16,12 +16,19 @@ type AWSConfigLoader interface {
 }
 
 // RealAWSConfigLoader implements AWSConfigLoader using the actual AWS SDK.
-type RealAWSConfigLoader struct{}
+// RetryMaxAttempts overrides the SDK retryer when set; use 1 when the clients are wrapped with WithRetries.
+type RealAWSConfigLoader struct {
+	RetryMaxAttempts int
+}
 
 // Load loads the AWS configuration using the AWS SDK.
 func (r *RealAWSConfigLoader) Load(ctx context.Context, region string) (aws.Config, error) {
+	optFns := []func(*config.LoadOptions) error{config.WithRegion(region)}
+	if r.RetryMaxAttempts > 0 {
+		optFns = append(optFns, config.WithRetryMaxAttempts(r.RetryMaxAttempts))
+	}
 
-	return config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	return config.LoadDefaultConfig(ctx, optFns...)
 }
 
 type AWSClients struct {
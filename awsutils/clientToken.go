@@ -0,0 +1,36 @@
+package awsutils
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+)
+
+// Client tokens are limited to 64 characters by the CreateJobTemplate API.
+const (
+	clientTokenMaxLength  = 64
+	clientTokenHashLength = 32
+)
+
+// ClientTokenGenerator builds the idempotency token sent with CreateJobTemplate.
+type ClientTokenGenerator interface {
+	Generate(name string, data *types.JobTemplateData) (string, error)
+}
+
+// RealClientTokenGenerator derives the client token from the template name and a hash of
+// the rendered template data, so a retried run returns the template created by the first attempt.
+// Like the fingerprint, the hash covers the flattened fields, not the SDK types.
+type RealClientTokenGenerator struct{}
+
+// Generate returns "<name prefix>-<hash>", truncating the name so the token fits the API limit.
+func (r *RealClientTokenGenerator) Generate(name string, data *types.JobTemplateData) (string, error) {
+	hash := hashFields(jobTemplateFields(name, "", data, nil))[:clientTokenHashLength]
+
+	prefix := name
+	if maxPrefix := clientTokenMaxLength - clientTokenHashLength - 1; len(prefix) > maxPrefix {
+		prefix = prefix[:maxPrefix]
+	}
+	if prefix == "" {
+		return hash, nil
+	}
+
+	return prefix + "-" + hash, nil
+}
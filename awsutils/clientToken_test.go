@@ -0,0 +1,53 @@
+package awsutils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealClientTokenGenerator_Deterministic(t *testing.T) {
+	t.Parallel()
+	generator := &awsutils.RealClientTokenGenerator{}
+
+	first, err := generator.Generate("test-job-template", testJobTemplateData())
+	require.NoError(t, err)
+	second, err := generator.Generate("test-job-template", testJobTemplateData())
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second, "identical content must produce the same token")
+	assert.True(t, strings.HasPrefix(first, "test-job-template-"), first)
+}
+
+func TestRealClientTokenGenerator_ContentChange(t *testing.T) {
+	t.Parallel()
+	generator := &awsutils.RealClientTokenGenerator{}
+	changed := testJobTemplateData()
+	changed.ReleaseLabel = aws.String("emr-6.4.0")
+
+	first, err := generator.Generate("test-job-template", testJobTemplateData())
+	require.NoError(t, err)
+	second, err := generator.Generate("test-job-template", changed)
+	require.NoError(t, err)
+	renamed, err := generator.Generate("other-job-template", testJobTemplateData())
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+	assert.NotEqual(t, first, renamed)
+}
+
+func TestRealClientTokenGenerator_LongName(t *testing.T) {
+	t.Parallel()
+	generator := &awsutils.RealClientTokenGenerator{}
+	name := strings.Repeat("a", 200)
+
+	token, err := generator.Generate(name, testJobTemplateData())
+	require.NoError(t, err)
+
+	assert.Len(t, token, 64)
+	assert.True(t, strings.HasPrefix(token, strings.Repeat("a", 31)+"-"), token)
+}
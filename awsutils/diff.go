@@ -0,0 +1,173 @@
+package awsutils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+)
+
+// FieldDiff describes a single field that differs between two job templates.
+// An empty Before means the field is added, an empty After means it is removed.
+type FieldDiff struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// DiffJobTemplateData compares the deployed job template data with the desired one
+// and returns the differing fields sorted by field path.
+func DiffJobTemplateData(current, desired *types.JobTemplateData) []FieldDiff {
+	return diffFields(flattenJobTemplateData(current), flattenJobTemplateData(desired))
+}
+
+// DiffJobTemplate compares a deployed job template with the input that would create it, including
+// the template name, KMS key and tags, and returns the differing fields sorted by field path. This
+// is the rule plan and apply share: a job template without differences is unchanged. A nil
+// current template is compared as empty.
+func DiffJobTemplate(current *types.JobTemplate, desired *emrcontainers.CreateJobTemplateInput) []FieldDiff {
+	before := make(map[string]string)
+	if current != nil {
+		before = jobTemplateFields(aws.ToString(current.Name), aws.ToString(current.KmsKeyArn), current.JobTemplateData, current.Tags)
+	}
+
+	return diffFields(before, inputFields(desired))
+}
+
+// inputFields is the canonical form of a CreateJobTemplateInput, as compared by DiffJobTemplate.
+func inputFields(input *emrcontainers.CreateJobTemplateInput) map[string]string {
+	return jobTemplateFields(aws.ToString(input.Name), aws.ToString(input.KmsKeyArn), input.JobTemplateData, input.Tags)
+}
+
+// jobTemplateFields flattens a job template into field path/value pairs. The fingerprint tag is
+// derived from these fields and left out.
+func jobTemplateFields(name, kmsKeyArn string, data *types.JobTemplateData, tags map[string]string) map[string]string {
+	fields := flattenJobTemplateData(data)
+	if name != "" {
+		fields["name"] = name
+	}
+	if kmsKeyArn != "" {
+		fields["kmsKeyArn"] = kmsKeyArn
+	}
+	for key, value := range tags {
+		if key != FingerprintTag && value != "" {
+			fields["tags."+key] = value
+		}
+	}
+
+	return fields
+}
+
+// diffFields returns the fields whose values differ, sorted by field path.
+func diffFields(before, after map[string]string) []FieldDiff {
+	fields := make(map[string]struct{}, len(before)+len(after))
+	for field := range before {
+		fields[field] = struct{}{}
+	}
+	for field := range after {
+		fields[field] = struct{}{}
+	}
+
+	var diffs []FieldDiff
+	for field := range fields {
+		if before[field] != after[field] {
+			diffs = append(diffs, FieldDiff{Field: field, Before: before[field], After: after[field]})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Field < diffs[j].Field
+	})
+
+	return diffs
+}
+
+// flattenJobTemplateData turns job template data into field path/value pairs.
+// Empty values are skipped so that nil and empty fields compare equal.
+func flattenJobTemplateData(data *types.JobTemplateData) map[string]string {
+	fields := make(map[string]string)
+	if data == nil {
+		return fields
+	}
+
+	set := func(field, value string) {
+		if value != "" {
+			fields[field] = value
+		}
+	}
+
+	set("executionRoleArn", aws.ToString(data.ExecutionRoleArn))
+	set("releaseLabel", aws.ToString(data.ReleaseLabel))
+
+	if data.JobDriver != nil && data.JobDriver.SparkSubmitJobDriver != nil {
+		driver := data.JobDriver.SparkSubmitJobDriver
+		set("jobDriver.entryPoint", aws.ToString(driver.EntryPoint))
+		for i, arg := range driver.EntryPointArguments {
+			set(fmt.Sprintf("jobDriver.entryPointArguments[%d]", i), arg)
+		}
+		flattenSparkSubmitParameters(aws.ToString(driver.SparkSubmitParameters), set)
+	}
+
+	if overrides := data.ConfigurationOverrides; overrides != nil {
+		for i, appConfig := range overrides.ApplicationConfiguration {
+			prefix := fmt.Sprintf("applicationConfiguration[%d]", i)
+			flattenConfiguration(prefix, appConfig, set)
+		}
+
+		if monitoring := overrides.MonitoringConfiguration; monitoring != nil {
+			set("monitoring.persistentAppUI", aws.ToString(monitoring.PersistentAppUI))
+			if cloudWatch := monitoring.CloudWatchMonitoringConfiguration; cloudWatch != nil {
+				set("monitoring.logGroupName", aws.ToString(cloudWatch.LogGroupName))
+				set("monitoring.logStreamNamePrefix", aws.ToString(cloudWatch.LogStreamNamePrefix))
+			}
+			if s3 := monitoring.S3MonitoringConfiguration; s3 != nil {
+				set("monitoring.s3MonitoringConfiguration.logUri", aws.ToString(s3.LogUri))
+			}
+		}
+	}
+
+	for name, param := range data.ParameterConfiguration {
+		set("parameterConfiguration."+name+".type", string(param.Type))
+		set("parameterConfiguration."+name+".defaultValue", aws.ToString(param.DefaultValue))
+	}
+
+	for key, value := range data.JobTags {
+		set("jobTags."+key, value)
+	}
+
+	return fields
+}
+
+// flattenConfiguration sets an application configuration and its nested configurations.
+func flattenConfiguration(prefix string, appConfig types.Configuration, set func(field, value string)) {
+	set(prefix+".classification", aws.ToString(appConfig.Classification))
+	for key, value := range appConfig.Properties {
+		set(prefix+".properties."+key, value)
+	}
+	for i, nested := range appConfig.Configurations {
+		flattenConfiguration(fmt.Sprintf("%s.configurations[%d]", prefix, i), nested, set)
+	}
+}
+
+// flattenSparkSubmitParameters sets the parsed Spark submit parameters field by field, so spacing
+// and flag order do not show up as differences. Values are compared without their quotes, as
+// spark-submit receives them. Each --conf is keyed by its property, other arguments by position.
+func flattenSparkSubmitParameters(parameters string, set func(field, value string)) {
+	const prefix = "jobDriver.sparkSubmitParameters."
+
+	params := ParseSparkSubmitCommand(parameters)
+	set(prefix+"master", unquoteSparkSubmitArgument(params.Master))
+	set(prefix+"deployMode", unquoteSparkSubmitArgument(params.DeployMode))
+	set(prefix+"class", unquoteSparkSubmitArgument(params.Class))
+	for _, conf := range params.Conf {
+		key, value, _ := strings.Cut(unquoteSparkSubmitArgument(conf), "=")
+		set(prefix+"conf."+key, value)
+	}
+	set(prefix+"packages", unquoteSparkSubmitArgument(params.Packages))
+	for i, arg := range params.ExtraArgs {
+		set(fmt.Sprintf("%sextraArgs[%d]", prefix, i), unquoteSparkSubmitArgument(arg))
+	}
+}
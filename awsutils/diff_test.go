@@ -0,0 +1,136 @@
+package awsutils_test
+
+import (
+	"testing"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testJobTemplateData() *types.JobTemplateData {
+	return &types.JobTemplateData{
+		ExecutionRoleArn: aws.String("arn:aws:iam::123456789012:role/EMRExecutionRole"),
+		ReleaseLabel:     aws.String("emr-6.2.0"),
+		JobDriver: &types.JobDriver{
+			SparkSubmitJobDriver: &types.SparkSubmitJobDriver{
+				EntryPoint:            aws.String("s3://my-bucket/my-script.py"),
+				EntryPointArguments:   []string{"--input", "s3://my-bucket/input"},
+				SparkSubmitParameters: aws.String("--master yarn"),
+			},
+		},
+		ConfigurationOverrides: &types.ParametricConfigurationOverrides{
+			ApplicationConfiguration: []types.Configuration{
+				{
+					Classification: aws.String("spark-defaults"),
+					Properties:     map[string]string{"spark.dynamicAllocation.enabled": "false"},
+				},
+			},
+			MonitoringConfiguration: &types.ParametricMonitoringConfiguration{
+				PersistentAppUI: aws.String("ENABLED"),
+				CloudWatchMonitoringConfiguration: &types.ParametricCloudWatchMonitoringConfiguration{
+					LogGroupName:        aws.String("/aws/emr-containers/jobs"),
+					LogStreamNamePrefix: aws.String("test-job-template"),
+				},
+			},
+		},
+		ParameterConfiguration: map[string]types.TemplateParameterConfiguration{
+			"Param1": {DefaultValue: aws.String("value"), Type: types.TemplateParameterDataTypeString},
+		},
+		JobTags: map[string]string{"Name": "test-job-template"},
+	}
+}
+
+func TestDiffJobTemplateData_Unchanged(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, awsutils.DiffJobTemplateData(testJobTemplateData(), testJobTemplateData()))
+}
+
+func TestDiffJobTemplateData_Changes(t *testing.T) {
+	t.Parallel()
+	current := testJobTemplateData()
+	desired := testJobTemplateData()
+	desired.ReleaseLabel = aws.String("emr-6.4.0")
+	desired.JobDriver.SparkSubmitJobDriver.EntryPointArguments = []string{"--input"}
+	desired.ConfigurationOverrides.ApplicationConfiguration[0].Properties["spark.executor.memory"] = "8G"
+	desired.JobTags["Owner"] = "team-x"
+
+	diffs := awsutils.DiffJobTemplateData(current, desired)
+
+	assert.Equal(t, []awsutils.FieldDiff{
+		{Field: "applicationConfiguration[0].properties.spark.executor.memory", After: "8G"},
+		{Field: "jobDriver.entryPointArguments[1]", Before: "s3://my-bucket/input"},
+		{Field: "jobTags.Owner", After: "team-x"},
+		{Field: "releaseLabel", Before: "emr-6.2.0", After: "emr-6.4.0"},
+	}, diffs)
+}
+
+func TestDiffJobTemplateData_Create(t *testing.T) {
+	t.Parallel()
+
+	diffs := awsutils.DiffJobTemplateData(nil, testJobTemplateData())
+
+	assert.NotEmpty(t, diffs)
+	for _, diff := range diffs {
+		assert.Empty(t, diff.Before, diff.Field)
+		assert.NotEmpty(t, diff.After, diff.Field)
+	}
+}
+
+func TestDiffJobTemplateData_SparkSubmitParameters(t *testing.T) {
+	t.Parallel()
+	current := testJobTemplateData()
+	current.JobDriver.SparkSubmitJobDriver.SparkSubmitParameters = aws.String("--master yarn --conf spark.a=1 --conf spark.b=2 --num-executors 4")
+	reordered := testJobTemplateData()
+	reordered.JobDriver.SparkSubmitJobDriver.SparkSubmitParameters = aws.String("--conf spark.b=2  --master=yarn --conf spark.a=1 --num-executors 4")
+	changed := testJobTemplateData()
+	changed.JobDriver.SparkSubmitJobDriver.SparkSubmitParameters = aws.String("--master yarn --conf spark.a=3 --num-executors 8")
+
+	assert.Empty(t, awsutils.DiffJobTemplateData(current, reordered))
+	assert.Equal(t, []awsutils.FieldDiff{
+		{Field: "jobDriver.sparkSubmitParameters.conf.spark.a", Before: "1", After: "3"},
+		{Field: "jobDriver.sparkSubmitParameters.conf.spark.b", Before: "2"},
+		{Field: "jobDriver.sparkSubmitParameters.extraArgs[1]", Before: "4", After: "8"},
+	}, awsutils.DiffJobTemplateData(current, changed))
+}
+
+func TestDiffJobTemplateData_QuotedSparkSubmitParameters(t *testing.T) {
+	t.Parallel()
+	current := testJobTemplateData()
+	current.JobDriver.SparkSubmitJobDriver.SparkSubmitParameters = aws.String(`--conf "spark.driver.extraJavaOptions=-Dfoo=1 -Dbar=2" --name 'nightly etl'`)
+	requoted := testJobTemplateData()
+	requoted.JobDriver.SparkSubmitJobDriver.SparkSubmitParameters = aws.String(`--conf spark.driver.extraJavaOptions='-Dfoo=1 -Dbar=2' --name "nightly etl"`)
+	changed := testJobTemplateData()
+	changed.JobDriver.SparkSubmitJobDriver.SparkSubmitParameters = aws.String(`--conf spark.driver.extraJavaOptions='-Dfoo=1' --name nightly etl`)
+
+	assert.Empty(t, awsutils.DiffJobTemplateData(current, requoted))
+	assert.Equal(t, []awsutils.FieldDiff{
+		{Field: "jobDriver.sparkSubmitParameters.conf.spark.driver.extraJavaOptions", Before: "-Dfoo=1 -Dbar=2", After: "-Dfoo=1"},
+		{Field: "jobDriver.sparkSubmitParameters.extraArgs[1]", Before: "nightly etl", After: "nightly"},
+		{Field: "jobDriver.sparkSubmitParameters.extraArgs[2]", After: "etl"},
+	}, awsutils.DiffJobTemplateData(current, changed))
+}
+
+func TestDiffJobTemplate(t *testing.T) {
+	t.Parallel()
+	desired := testCreateJobTemplateInput()
+	awsutils.SetFingerprintTag(desired)
+	deployed := &types.JobTemplate{
+		Name:            aws.String("test-job-template"),
+		Tags:            map[string]string{"Name": "test-job-template", "Environment": "prod"},
+		JobTemplateData: testJobTemplateData(),
+	}
+	deployed.JobTemplateData.JobTags = map[string]string{"Name": "test-job-template", "Environment": "test"}
+	deployed.JobTemplateData.ConfigurationOverrides.ApplicationConfiguration[0].Configurations = []types.Configuration{
+		{Classification: aws.String("export"), Properties: map[string]string{"PYSPARK_PYTHON": "python3"}},
+	}
+
+	// The deployed template has no fingerprint tag, which is not a difference.
+	assert.Equal(t, []awsutils.FieldDiff{
+		{Field: "applicationConfiguration[0].configurations[0].classification", Before: "export"},
+		{Field: "applicationConfiguration[0].configurations[0].properties.PYSPARK_PYTHON", Before: "python3"},
+		{Field: "tags.Environment", Before: "prod", After: "test"},
+	}, awsutils.DiffJobTemplate(deployed, desired))
+}
@@ -2,6 +2,7 @@ package awsutils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -34,6 +35,25 @@ func (r *RealSparkSubmitCommandBuilder) Build(params template.SparkSubmitParamet
 type EMRC interface {
 	DescribeJobTemplate(ctx context.Context, params *emrcontainers.DescribeJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobTemplateOutput, error)
 	CreateJobTemplate(ctx context.Context, params *emrcontainers.CreateJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CreateJobTemplateOutput, error)
+	ListJobTemplates(ctx context.Context, params *emrcontainers.ListJobTemplatesInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.ListJobTemplatesOutput, error)
+	DeleteJobTemplate(ctx context.Context, params *emrcontainers.DeleteJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DeleteJobTemplateOutput, error)
+	StartJobRun(ctx context.Context, params *emrcontainers.StartJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.StartJobRunOutput, error)
+	DescribeJobRun(ctx context.Context, params *emrcontainers.DescribeJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobRunOutput, error)
+	CancelJobRun(ctx context.Context, params *emrcontainers.CancelJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CancelJobRunOutput, error)
+	TagResource(ctx context.Context, params *emrcontainers.TagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.TagResourceOutput, error)
+	UntagResource(ctx context.Context, params *emrcontainers.UntagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.UntagResourceOutput, error)
+}
+
+// Tag set on a job template whose smoke test failed.
+const (
+	StatusTag         = "status"
+	StatusFailedSmoke = "failed-smoke"
+)
+
+// IsFailedSmokeTest reports whether a job template is tagged as having failed its smoke test.
+// Such templates were never published and are not deployed, rolled back to or run by name.
+func IsFailedSmokeTest(jobTemplate types.JobTemplate) bool {
+	return jobTemplate.Tags[StatusTag] == StatusFailedSmoke
 }
 
 func DescribeJobTemplate(ctx context.Context, client EMRC, jobTemplateID string) (*types.JobTemplate, error) {
@@ -52,17 +72,94 @@ func DescribeJobTemplate(ctx context.Context, client EMRC, jobTemplateID string)
 	return resp.JobTemplate, nil
 }
 
+// TagJobTemplate adds tags to a job template, replacing the values of existing keys.
+func TagJobTemplate(ctx context.Context, client EMRC, jobTemplateArn string, tags map[string]string) error {
+	_, err := client.TagResource(ctx, &emrcontainers.TagResourceInput{
+		ResourceArn: &jobTemplateArn,
+		Tags:        tags,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag job template: %w", err)
+	}
+
+	return nil
+}
+
+// UntagJobTemplate removes tags from a job template.
+func UntagJobTemplate(ctx context.Context, client EMRC, jobTemplateArn string, keys ...string) error {
+	_, err := client.UntagResource(ctx, &emrcontainers.UntagResourceInput{
+		ResourceArn: &jobTemplateArn,
+		TagKeys:     keys,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to untag job template: %w", err)
+	}
+
+	return nil
+}
+
+// IsJobTemplateNotFound reports whether err was caused by a job template that does not exist.
+func IsJobTemplateNotFound(err error) bool {
+	var notFound *types.ResourceNotFoundException
+
+	return errors.As(err, &notFound)
+}
+
+// ListJobTemplates returns every job template in the account and region.
+func ListJobTemplates(ctx context.Context, client EMRC) ([]types.JobTemplate, error) {
+	var templates []types.JobTemplate
+
+	paginator := emrcontainers.NewListJobTemplatesPaginator(client, &emrcontainers.ListJobTemplatesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list job templates: %w", err)
+		}
+		templates = append(templates, page.Templates...)
+	}
+
+	return templates, nil
+}
+
+// JobTemplateName returns the logical name of a job template, preferring the
+// "Name" tag set by PrepareJobTemplateInput over the template name itself.
+func JobTemplateName(jobTemplate types.JobTemplate) string {
+	if name, ok := jobTemplate.Tags["Name"]; ok {
+		return name
+	}
+
+	return aws.ToString(jobTemplate.Name)
+}
+
+// FindLatestJobTemplate returns the most recently created template with the given name, or nil.
+// Templates that failed their smoke test are skipped.
+func FindLatestJobTemplate(templates []types.JobTemplate, name string) *types.JobTemplate {
+	var latest *types.JobTemplate
+	for i := range templates {
+		if JobTemplateName(templates[i]) != name || IsFailedSmokeTest(templates[i]) {
+			continue
+		}
+		if latest == nil || aws.ToTime(templates[i].CreatedAt).After(aws.ToTime(latest.CreatedAt)) {
+			latest = &templates[i]
+		}
+	}
+
+	return latest
+}
+
 func PrepareJobTemplateInput(
 	jobConfig template.JobTemplateConfig,
 	parameterConfigurator ParameterConfigurator,
 	sparkSubmitCommandBuilder SparkSubmitCommandBuilder,
-	randomIntn func(int) int,
+	clientTokenGenerator ClientTokenGenerator,
 ) (*emrcontainers.CreateJobTemplateInput, error) {
-	// Ensure the "Name" tag is set
-	if jobConfig.Tags == nil {
-		jobConfig.Tags = make(map[string]string)
+	// Ensure the "Name" tag is set, on a copy so the caller's tags are left untouched.
+	tags := make(map[string]string, len(jobConfig.Tags)+1)
+	for key, value := range jobConfig.Tags {
+		tags[key] = value
 	}
-	jobConfig.Tags["Name"] = jobConfig.Name
+	tags["Name"] = jobConfig.Name
+	jobConfig.Tags = tags
 
 	// Call helperParameterConfiguration.
 	parameterConfig, err := parameterConfigurator.Configure(jobConfig.ParameterConfiguration)
@@ -85,37 +182,43 @@ func PrepareJobTemplateInput(
 		})
 	}
 
-	// Generate a client token using the injected randomIntn function.
-	clientToken := aws.String(fmt.Sprintf("%d", randomIntn(100000)))
-
-	// Construct the CreateJobTemplateInput.
-	input := &emrcontainers.CreateJobTemplateInput{
-		Name: aws.String(jobConfig.Name),
-		JobTemplateData: &types.JobTemplateData{
-			ExecutionRoleArn: aws.String(jobConfig.ExecutionRoleArn),
-			ReleaseLabel:     aws.String(jobConfig.ReleaseLabel),
-			JobDriver: &types.JobDriver{
-				SparkSubmitJobDriver: &types.SparkSubmitJobDriver{
-					EntryPoint:            aws.String(jobConfig.EntryPoint),
-					EntryPointArguments:   jobConfig.EntryPointArguments,
-					SparkSubmitParameters: aws.String(sparkSubmitParametersConfig),
-				},
+	// Construct the job template data.
+	jobTemplateData := &types.JobTemplateData{
+		ExecutionRoleArn: aws.String(jobConfig.ExecutionRoleArn),
+		ReleaseLabel:     aws.String(jobConfig.ReleaseLabel),
+		JobDriver: &types.JobDriver{
+			SparkSubmitJobDriver: &types.SparkSubmitJobDriver{
+				EntryPoint:            aws.String(jobConfig.EntryPoint),
+				EntryPointArguments:   jobConfig.EntryPointArguments,
+				SparkSubmitParameters: aws.String(sparkSubmitParametersConfig),
 			},
-			ConfigurationOverrides: &types.ParametricConfigurationOverrides{
-				ApplicationConfiguration: appConfigs,
-				MonitoringConfiguration: &types.ParametricMonitoringConfiguration{
-					PersistentAppUI: aws.String(jobConfig.PersistentAppUI),
-					CloudWatchMonitoringConfiguration: &types.ParametricCloudWatchMonitoringConfiguration{
-						LogGroupName:        aws.String(jobConfig.LogGroupName),
-						LogStreamNamePrefix: aws.String(jobConfig.Name),
-					},
+		},
+		ConfigurationOverrides: &types.ParametricConfigurationOverrides{
+			ApplicationConfiguration: appConfigs,
+			MonitoringConfiguration: &types.ParametricMonitoringConfiguration{
+				PersistentAppUI: aws.String(jobConfig.PersistentAppUI),
+				CloudWatchMonitoringConfiguration: &types.ParametricCloudWatchMonitoringConfiguration{
+					LogGroupName:        aws.String(jobConfig.LogGroupName),
+					LogStreamNamePrefix: aws.String(jobConfig.Name),
 				},
 			},
-			ParameterConfiguration: parameterConfig,
-			JobTags:                jobConfig.Tags,
 		},
-		ClientToken: clientToken,
-		Tags:        jobConfig.Tags,
+		ParameterConfiguration: parameterConfig,
+		JobTags:                jobConfig.Tags,
+	}
+
+	// Derive the client token from the template content using the injected generator.
+	clientToken, err := clientTokenGenerator.Generate(jobConfig.Name, jobTemplateData)
+	if err != nil {
+		return nil, fmt.Errorf("client token generation failed: %w", err)
+	}
+
+	// Construct the CreateJobTemplateInput.
+	input := &emrcontainers.CreateJobTemplateInput{
+		Name:            aws.String(jobConfig.Name),
+		JobTemplateData: jobTemplateData,
+		ClientToken:     aws.String(clientToken),
+		Tags:            jobConfig.Tags,
 	}
 
 	return input, nil
@@ -187,5 +290,14 @@ func HelpersBuildSparkSubmitCommand(params template.SparkSubmitParameters) (stri
 		return "", fmt.Errorf("missing required parameter: packages")
 	}
 
+	// Passthrough arguments, such as flags without a field, go last in their original order.
+	// Like the other values they are written as is, quotes included.
+	for _, arg := range params.ExtraArgs {
+		if arg == "" {
+			return "", fmt.Errorf("extra_args contains an empty value")
+		}
+		result.WriteString(" " + arg)
+	}
+
 	return strings.TrimSpace(result.String()), nil
 }
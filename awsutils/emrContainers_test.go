@@ -33,6 +33,13 @@ func (m *MockEMRAWSConfigLoader) LoadConfig(ctx context.Context, region string)
 type MockEMRCclient struct {
 	DescribeJobTemplateFunc func(ctx context.Context, params *emrcontainers.DescribeJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobTemplateOutput, error)
 	CreateJobTemplateFunc   func(ctx context.Context, params *emrcontainers.CreateJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CreateJobTemplateOutput, error)
+	ListJobTemplatesFunc    func(ctx context.Context, params *emrcontainers.ListJobTemplatesInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.ListJobTemplatesOutput, error)
+	DeleteJobTemplateFunc   func(ctx context.Context, params *emrcontainers.DeleteJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DeleteJobTemplateOutput, error)
+	StartJobRunFunc         func(ctx context.Context, params *emrcontainers.StartJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.StartJobRunOutput, error)
+	DescribeJobRunFunc      func(ctx context.Context, params *emrcontainers.DescribeJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobRunOutput, error)
+	CancelJobRunFunc        func(ctx context.Context, params *emrcontainers.CancelJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CancelJobRunOutput, error)
+	TagResourceFunc         func(ctx context.Context, params *emrcontainers.TagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.TagResourceOutput, error)
+	UntagResourceFunc       func(ctx context.Context, params *emrcontainers.UntagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.UntagResourceOutput, error)
 }
 
 func (m *MockEMRCclient) DescribeJobTemplate(ctx context.Context, params *emrcontainers.DescribeJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobTemplateOutput, error) {
@@ -43,6 +50,39 @@ func (m *MockEMRCclient) CreateJobTemplate(ctx context.Context, params *emrconta
 
 	return m.CreateJobTemplateFunc(ctx, params, optFns...)
 }
+func (m *MockEMRCclient) ListJobTemplates(ctx context.Context, params *emrcontainers.ListJobTemplatesInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.ListJobTemplatesOutput, error) {
+
+	return m.ListJobTemplatesFunc(ctx, params, optFns...)
+}
+func (m *MockEMRCclient) DeleteJobTemplate(ctx context.Context, params *emrcontainers.DeleteJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DeleteJobTemplateOutput, error) {
+
+	return m.DeleteJobTemplateFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) StartJobRun(ctx context.Context, params *emrcontainers.StartJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.StartJobRunOutput, error) {
+
+	return m.StartJobRunFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) DescribeJobRun(ctx context.Context, params *emrcontainers.DescribeJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobRunOutput, error) {
+
+	return m.DescribeJobRunFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) CancelJobRun(ctx context.Context, params *emrcontainers.CancelJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CancelJobRunOutput, error) {
+
+	return m.CancelJobRunFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) TagResource(ctx context.Context, params *emrcontainers.TagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.TagResourceOutput, error) {
+
+	return m.TagResourceFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) UntagResource(ctx context.Context, params *emrcontainers.UntagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.UntagResourceOutput, error) {
+
+	return m.UntagResourceFunc(ctx, params, optFns...)
+}
 
 // MockParameterConfigurator is a mock implementation of ParameterConfigurator.
 type MockParameterConfigurator struct {
@@ -70,11 +110,15 @@ func (m *MockSparkSubmitCommandBuilder) Build(params template.SparkSubmitParamet
 	return args.String(0), args.Error(1)
 }
 
-func MockRandomIntn(returnValue int) func(int) int {
-	return func(n int) int {
+// MockClientTokenGenerator is a mock implementation of ClientTokenGenerator.
+type MockClientTokenGenerator struct {
+	mock.Mock
+}
 
-		return returnValue
-	}
+func (m *MockClientTokenGenerator) Generate(name string, data *types.JobTemplateData) (string, error) {
+	args := m.Called(name, data)
+
+	return args.String(0), args.Error(1)
 }
 
 func TestPrepareJobTemplateInput_Success(t *testing.T) {
@@ -134,11 +178,12 @@ func TestPrepareJobTemplateInput_Success(t *testing.T) {
 	mockCommandBuilder := new(MockSparkSubmitCommandBuilder)
 	mockCommandBuilder.On("Build", jobConfig.SparkSubmitParameters).Return(expectedSparkSubmitParametersConfig, nil)
 
-	// Mock randomIntn to return a fixed value.
-	mockRandom := MockRandomIntn(12345)
+	// Mock the client token generator to return a fixed value.
+	mockTokenGenerator := new(MockClientTokenGenerator)
+	mockTokenGenerator.On("Generate", "test-job-template", mock.Anything).Return("12345", nil)
 
 	// Call PrepareJobTemplateInput.
-	input, err := awsutils.PrepareJobTemplateInput(jobConfig, mockConfigurator, mockCommandBuilder, mockRandom)
+	input, err := awsutils.PrepareJobTemplateInput(jobConfig, mockConfigurator, mockCommandBuilder, mockTokenGenerator)
 
 	// Assertions.
 	require.NoError(t, err)
@@ -176,6 +221,7 @@ func TestPrepareJobTemplateInput_Success(t *testing.T) {
 	// Assert that the mocks were called as expected.
 	mockConfigurator.AssertExpectations(t)
 	mockCommandBuilder.AssertExpectations(t)
+	mockTokenGenerator.AssertExpectations(t)
 }
 
 func TestPrepareJobTemplateInput_HelperParameterConfigurationError(t *testing.T) {
@@ -225,11 +271,11 @@ func TestPrepareJobTemplateInput_HelperParameterConfigurationError(t *testing.T)
 	// The command builder should not be called; hence, no expectation set.
 	mockCommandBuilder := new(MockSparkSubmitCommandBuilder)
 
-	// Mock randomIntn (should not be used).
-	mockRandom := MockRandomIntn(12345)
+	// The client token generator should not be called; hence, no expectation set.
+	mockTokenGenerator := new(MockClientTokenGenerator)
 
 	// Call PrepareJobTemplateInput.
-	input, err := awsutils.PrepareJobTemplateInput(jobConfig, mockConfigurator, mockCommandBuilder, mockRandom)
+	input, err := awsutils.PrepareJobTemplateInput(jobConfig, mockConfigurator, mockCommandBuilder, mockTokenGenerator)
 
 	// Assertions.
 	require.Error(t, err)
@@ -240,6 +286,7 @@ func TestPrepareJobTemplateInput_HelperParameterConfigurationError(t *testing.T)
 	mockConfigurator.AssertExpectations(t)
 	// Since helpersBuildSparkSubmitCommand should not be called, verify no expectations.
 	mockCommandBuilder.AssertExpectations(t)
+	mockTokenGenerator.AssertExpectations(t)
 }
 
 func TestPrepareJobTemplateInput_HelpersBuildSparkSubmitCommandError(t *testing.T) {
@@ -297,11 +344,11 @@ func TestPrepareJobTemplateInput_HelpersBuildSparkSubmitCommandError(t *testing.
 	mockCommandBuilder := new(MockSparkSubmitCommandBuilder)
 	mockCommandBuilder.On("Build", jobConfig.SparkSubmitParameters).Return("", fmt.Errorf("mocked helpersBuildSparkSubmitCommand error"))
 
-	// Mock randomIntn (should not be used).
-	mockRandom := MockRandomIntn(12345)
+	// The client token generator should not be called; hence, no expectation set.
+	mockTokenGenerator := new(MockClientTokenGenerator)
 
 	// Call PrepareJobTemplateInput.
-	input, err := awsutils.PrepareJobTemplateInput(jobConfig, mockConfigurator, mockCommandBuilder, mockRandom)
+	input, err := awsutils.PrepareJobTemplateInput(jobConfig, mockConfigurator, mockCommandBuilder, mockTokenGenerator)
 
 	// Assertions.
 	require.Error(t, err)
@@ -311,6 +358,7 @@ func TestPrepareJobTemplateInput_HelpersBuildSparkSubmitCommandError(t *testing.
 	// Assert that the mocks were called as expected.
 	mockConfigurator.AssertExpectations(t)
 	mockCommandBuilder.AssertExpectations(t)
+	mockTokenGenerator.AssertExpectations(t)
 }
 
 func TestPrepareJobTemplateInput_NilTags(t *testing.T) {
@@ -368,11 +416,12 @@ func TestPrepareJobTemplateInput_NilTags(t *testing.T) {
 	mockCommandBuilder := new(MockSparkSubmitCommandBuilder)
 	mockCommandBuilder.On("Build", jobConfig.SparkSubmitParameters).Return(expectedSparkSubmitParametersConfig, nil)
 
-	// Mock randomIntn to return a fixed value.
-	mockRandom := MockRandomIntn(12345)
+	// Mock the client token generator to return a fixed value.
+	mockTokenGenerator := new(MockClientTokenGenerator)
+	mockTokenGenerator.On("Generate", "test-job-template", mock.Anything).Return("12345", nil)
 
 	// Call PrepareJobTemplateInput.
-	input, err := awsutils.PrepareJobTemplateInput(jobConfig, mockConfigurator, mockCommandBuilder, mockRandom)
+	input, err := awsutils.PrepareJobTemplateInput(jobConfig, mockConfigurator, mockCommandBuilder, mockTokenGenerator)
 
 	// Assertions.
 	require.NoError(t, err)
@@ -409,6 +458,7 @@ func TestPrepareJobTemplateInput_NilTags(t *testing.T) {
 	// Assert that the mocks were called as expected.
 	mockConfigurator.AssertExpectations(t)
 	mockCommandBuilder.AssertExpectations(t)
+	mockTokenGenerator.AssertExpectations(t)
 }
 
 func TestDescribeJobTemplate_Success(t *testing.T) {
@@ -569,6 +619,34 @@ func Test_helpersBuildSparkSubmitCommand(t *testing.T) {
 			want:    "--master local[*] --deploy-mode client --class org.example.Main --conf spark.executor.memory=2g --conf spark.driver.memory=1g --packages org.apache.spark:spark-sql_2.12:3.0.1",
 			wantErr: false,
 		},
+		{
+			name: "Extra Args",
+			args: args{
+				params: template.SparkSubmitParameters{
+					Master:     "yarn",
+					DeployMode: "cluster",
+					Class:      "org.example.Main",
+					Packages:   "org.apache.spark:spark-sql_2.12:3.0.1",
+					ExtraArgs:  []string{"--num-executors", "4", "--name", "'my job'"},
+				},
+			},
+			want:    "--master yarn --deploy-mode cluster --class org.example.Main --packages org.apache.spark:spark-sql_2.12:3.0.1 --num-executors 4 --name 'my job'",
+			wantErr: false,
+		},
+		{
+			name: "Empty Extra Arg",
+			args: args{
+				params: template.SparkSubmitParameters{
+					Master:     "yarn",
+					DeployMode: "cluster",
+					Class:      "org.example.Main",
+					Packages:   "org.apache.spark:spark-sql_2.12:3.0.1",
+					ExtraArgs:  []string{""},
+				},
+			},
+			want:    "",
+			wantErr: true,
+		},
 		{
 			name: "Missing Master Parameter",
 			args: args{
@@ -716,3 +794,80 @@ func Test_helpersBuildSparkSubmitCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestListJobTemplates_Pagination(t *testing.T) {
+	t.Parallel()
+	mockClient := &MockEMRCclient{
+		ListJobTemplatesFunc: func(ctx context.Context, params *emrcontainers.ListJobTemplatesInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.ListJobTemplatesOutput, error) {
+			if params.NextToken == nil {
+				return &emrcontainers.ListJobTemplatesOutput{
+					Templates: []types.JobTemplate{{Id: aws.String("first")}},
+					NextToken: aws.String("page-2"),
+				}, nil
+			}
+
+			return &emrcontainers.ListJobTemplatesOutput{
+				Templates: []types.JobTemplate{{Id: aws.String("second")}},
+			}, nil
+		},
+	}
+
+	templates, err := awsutils.ListJobTemplates(context.Background(), mockClient)
+
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "first", aws.ToString(templates[0].Id))
+	assert.Equal(t, "second", aws.ToString(templates[1].Id))
+}
+
+func TestFindLatestJobTemplate(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	templates := []types.JobTemplate{
+		{Id: aws.String("old"), Tags: map[string]string{"Name": "job"}, CreatedAt: aws.Time(now.Add(-time.Hour))},
+		{Id: aws.String("new"), Tags: map[string]string{"Name": "job"}, CreatedAt: aws.Time(now)},
+		{Id: aws.String("other"), Tags: map[string]string{"Name": "other-job"}, CreatedAt: aws.Time(now.Add(time.Hour))},
+		{Id: aws.String("untagged"), Name: aws.String("untagged-job"), CreatedAt: aws.Time(now)},
+		{Id: aws.String("failed"), Tags: map[string]string{"Name": "job", awsutils.StatusTag: awsutils.StatusFailedSmoke}, CreatedAt: aws.Time(now.Add(time.Hour))},
+	}
+
+	latest := awsutils.FindLatestJobTemplate(templates, "job")
+	require.NotNil(t, latest)
+	assert.Equal(t, "new", aws.ToString(latest.Id))
+
+	untagged := awsutils.FindLatestJobTemplate(templates, "untagged-job")
+	require.NotNil(t, untagged)
+	assert.Equal(t, "untagged", aws.ToString(untagged.Id))
+
+	assert.Nil(t, awsutils.FindLatestJobTemplate(templates, "missing"))
+}
+
+func TestPrepareJobTemplateInput_ClientTokenError(t *testing.T) {
+	t.Parallel()
+	// Prepare a minimal jobConfig, the helpers are mocked.
+	jobConfig := template.JobTemplateConfig{
+		Name: "test-job-template",
+	}
+
+	// Initialize mocks.
+	mockConfigurator := new(MockParameterConfigurator)
+	mockConfigurator.On("Configure", jobConfig.ParameterConfiguration).Return(map[string]types.TemplateParameterConfiguration{}, nil)
+
+	mockCommandBuilder := new(MockSparkSubmitCommandBuilder)
+	mockCommandBuilder.On("Build", jobConfig.SparkSubmitParameters).Return("--master yarn", nil)
+
+	mockTokenGenerator := new(MockClientTokenGenerator)
+	mockTokenGenerator.On("Generate", "test-job-template", mock.Anything).Return("", fmt.Errorf("mocked client token error"))
+
+	// Call PrepareJobTemplateInput.
+	input, err := awsutils.PrepareJobTemplateInput(jobConfig, mockConfigurator, mockCommandBuilder, mockTokenGenerator)
+
+	// Assertions.
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "client token generation failed")
+	assert.Nil(t, input)
+
+	mockConfigurator.AssertExpectations(t)
+	mockCommandBuilder.AssertExpectations(t)
+	mockTokenGenerator.AssertExpectations(t)
+}
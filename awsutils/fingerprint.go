@@ -0,0 +1,51 @@
+package awsutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+)
+
+// FingerprintTag is the job template tag holding the content fingerprint.
+const FingerprintTag = "TemplateFingerprint"
+
+// JobTemplateFingerprint returns a SHA-256 hash of the content of a job template input. It hashes
+// the flattened fields compared by DiffJobTemplate rather than the SDK types, so an SDK upgrade
+// does not change it. ClientToken and the fingerprint tag itself are left out.
+func JobTemplateFingerprint(input *emrcontainers.CreateJobTemplateInput) string {
+	return hashFields(inputFields(input))
+}
+
+// hashFields returns the hex encoded SHA-256 hash of field path/value pairs in field path order.
+// Every path and value is terminated by a NUL byte, which cannot occur in either.
+func hashFields(fields map[string]string) string {
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	hash := sha256.New()
+	for _, path := range paths {
+		hash.Write([]byte(path + "\x00" + fields[path] + "\x00"))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// SetFingerprintTag computes the fingerprint of the input and stores it in the template tags.
+// The tags are copied so that the job tags sharing the same map are left untouched.
+func SetFingerprintTag(input *emrcontainers.CreateJobTemplateInput) string {
+	fingerprint := JobTemplateFingerprint(input)
+
+	tags := make(map[string]string, len(input.Tags)+1)
+	for key, value := range input.Tags {
+		tags[key] = value
+	}
+	tags[FingerprintTag] = fingerprint
+	input.Tags = tags
+
+	return fingerprint
+}
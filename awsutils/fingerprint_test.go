@@ -0,0 +1,76 @@
+package awsutils_test
+
+import (
+	"testing"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCreateJobTemplateInput() *emrcontainers.CreateJobTemplateInput {
+	tags := map[string]string{"Name": "test-job-template", "Environment": "test"}
+	data := testJobTemplateData()
+	data.JobTags = tags
+
+	return &emrcontainers.CreateJobTemplateInput{
+		Name:            aws.String("test-job-template"),
+		JobTemplateData: data,
+		ClientToken:     aws.String("12345"),
+		Tags:            tags,
+	}
+}
+
+func TestJobTemplateFingerprint_Stable(t *testing.T) {
+	t.Parallel()
+	first := testCreateJobTemplateInput()
+	second := testCreateJobTemplateInput()
+	second.ClientToken = aws.String("67890")
+
+	firstFingerprint := awsutils.JobTemplateFingerprint(first)
+	secondFingerprint := awsutils.JobTemplateFingerprint(second)
+
+	assert.Len(t, firstFingerprint, 64)
+	assert.Equal(t, firstFingerprint, secondFingerprint, "client token must not affect the fingerprint")
+}
+
+func TestJobTemplateFingerprint_ContentChange(t *testing.T) {
+	t.Parallel()
+	first := testCreateJobTemplateInput()
+	second := testCreateJobTemplateInput()
+	second.JobTemplateData.ReleaseLabel = aws.String("emr-6.4.0")
+
+	firstFingerprint := awsutils.JobTemplateFingerprint(first)
+	secondFingerprint := awsutils.JobTemplateFingerprint(second)
+
+	assert.NotEqual(t, firstFingerprint, secondFingerprint)
+}
+
+func TestSetFingerprintTag(t *testing.T) {
+	t.Parallel()
+	input := testCreateJobTemplateInput()
+	before := awsutils.JobTemplateFingerprint(input)
+
+	fingerprint := awsutils.SetFingerprintTag(input)
+
+	assert.Equal(t, before, fingerprint)
+	assert.Equal(t, fingerprint, input.Tags[awsutils.FingerprintTag])
+	assert.NotContains(t, input.JobTemplateData.JobTags, awsutils.FingerprintTag, "job tags must not carry the fingerprint")
+
+	// The tag itself is ignored when fingerprinting again.
+	assert.Equal(t, fingerprint, awsutils.JobTemplateFingerprint(input))
+}
+
+func TestJobTemplateFingerprint_Canonical(t *testing.T) {
+	t.Parallel()
+	first := testCreateJobTemplateInput()
+	second := testCreateJobTemplateInput()
+	second.JobTemplateData.JobDriver.SparkSubmitJobDriver.SparkSubmitParameters = aws.String("--master=yarn")
+	second.JobTemplateData.ParameterConfiguration["Param1"] = types.TemplateParameterConfiguration{DefaultValue: aws.String("value"), Type: "STRING"}
+
+	// The hash covers the flattened fields, so it does not depend on how the SDK encodes its types.
+	assert.Equal(t, "f89cff008d6fb912bc92c2c7e5d84c3034084bc76516a3f17277b4fd39dbe6c7", awsutils.JobTemplateFingerprint(first))
+	assert.Equal(t, awsutils.JobTemplateFingerprint(first), awsutils.JobTemplateFingerprint(second))
+}
@@ -0,0 +1,145 @@
+package awsutils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoGstickGo/emr-containers-template/template"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+)
+
+// preparedFields are always set by PrepareJobTemplateInput, from the name. Job templates created
+// elsewhere may lack them, which does not prevent an import.
+var preparedFields = map[string]bool{"jobTags.Name": true, "monitoring.logStreamNamePrefix": true}
+
+// managedFields are tags set by apply after the template was created, not from the config.
+var managedFields = map[string]bool{"tags." + StatusTag: true}
+
+// ImportJobTemplate reverse-maps a described job template into a job template config. The config
+// is checked to build the same job template data again, so settings the YAML cannot express are
+// reported instead of being dropped.
+func ImportJobTemplate(jobTemplate types.JobTemplate) (template.JobTemplateConfig, error) {
+	name := JobTemplateName(jobTemplate)
+	jobConfig := template.JobTemplateConfig{Name: name}
+
+	data := jobTemplate.JobTemplateData
+	if data == nil {
+		return jobConfig, fmt.Errorf("job template '%s' has no job template data", name)
+	}
+	if unsupported := unsupportedJobTemplateFields(jobTemplate); len(unsupported) > 0 {
+		return jobConfig, fmt.Errorf("job template '%s' uses settings the YAML cannot express: %s", name, strings.Join(unsupported, ", "))
+	}
+
+	jobConfig.ExecutionRoleArn = aws.ToString(data.ExecutionRoleArn)
+	jobConfig.ReleaseLabel = aws.ToString(data.ReleaseLabel)
+
+	driver := data.JobDriver.SparkSubmitJobDriver
+	jobConfig.EntryPoint = aws.ToString(driver.EntryPoint)
+	jobConfig.EntryPointArguments = driver.EntryPointArguments
+	jobConfig.SparkSubmitParameters = ParseSparkSubmitCommand(aws.ToString(driver.SparkSubmitParameters))
+
+	importConfigurationOverrides(&jobConfig, data.ConfigurationOverrides)
+	jobConfig.ParameterConfiguration = importParameterConfiguration(data.ParameterConfiguration)
+
+	// The Name tag is set from the name by PrepareJobTemplateInput.
+	for key, value := range data.JobTags {
+		if key == "Name" {
+			continue
+		}
+		if jobConfig.Tags == nil {
+			jobConfig.Tags = make(map[string]string)
+		}
+		jobConfig.Tags[key] = value
+	}
+
+	// Build the job template again and compare, name and tags included, so the YAML is known to round-trip.
+	input, err := PrepareJobTemplateInput(jobConfig, &RealParameterConfigurator{}, &RealSparkSubmitCommandBuilder{}, &RealClientTokenGenerator{})
+	if err != nil {
+		return jobConfig, fmt.Errorf("job template '%s' cannot be rebuilt: %w", name, err)
+	}
+	var fields []string
+	for _, diff := range DiffJobTemplate(&jobTemplate, input) {
+		if importedFieldAdded(diff) || managedFields[diff.Field] {
+			continue
+		}
+		fields = append(fields, diff.Field)
+	}
+	if len(fields) > 0 {
+		return jobConfig, fmt.Errorf("job template '%s' would not round-trip, these fields differ: %s", name, strings.Join(fields, ", "))
+	}
+
+	return jobConfig, nil
+}
+
+// importedFieldAdded reports whether a difference only adds a prepared field or a template tag.
+// The config has one set of tags for the template and its job runs, so template tags are added to
+// templates created without them; template tags that differ from the job tags are not expressible.
+func importedFieldAdded(diff FieldDiff) bool {
+	return diff.Before == "" && (preparedFields[diff.Field] || strings.HasPrefix(diff.Field, "tags."))
+}
+
+// importConfigurationOverrides maps the application configurations and the monitoring settings.
+func importConfigurationOverrides(jobConfig *template.JobTemplateConfig, overrides *types.ParametricConfigurationOverrides) {
+	if overrides == nil {
+		return
+	}
+	for _, appConfig := range overrides.ApplicationConfiguration {
+		jobConfig.ApplicationConfigurations = append(jobConfig.ApplicationConfigurations, template.ApplicationConfiguration{
+			Classification: aws.ToString(appConfig.Classification),
+			Properties:     appConfig.Properties,
+		})
+	}
+	if monitoring := overrides.MonitoringConfiguration; monitoring != nil {
+		jobConfig.PersistentAppUI = aws.ToString(monitoring.PersistentAppUI)
+		if cloudWatch := monitoring.CloudWatchMonitoringConfiguration; cloudWatch != nil {
+			jobConfig.LogGroupName = aws.ToString(cloudWatch.LogGroupName)
+		}
+	}
+}
+
+// importParameterConfiguration maps the template parameters, nil without any.
+func importParameterConfiguration(params map[string]types.TemplateParameterConfiguration) map[string]template.TemplateParameterConfiguration {
+	if len(params) == 0 {
+		return nil
+	}
+	paramConfig := make(map[string]template.TemplateParameterConfiguration, len(params))
+	for key, param := range params {
+		paramConfig[key] = template.TemplateParameterConfiguration{
+			DefaultValue: param.DefaultValue,
+			Type:         param.Type,
+		}
+	}
+
+	return paramConfig
+}
+
+// unsupportedJobTemplateFields lists the job template settings without a job template config field.
+// The caller checks that the job template data is set.
+func unsupportedJobTemplateFields(jobTemplate types.JobTemplate) []string {
+	var unsupported []string
+	// A template encrypted with a KMS key would be replaced by an unencrypted one on the next apply.
+	if aws.ToString(jobTemplate.KmsKeyArn) != "" {
+		unsupported = append(unsupported, "kmsKeyArn")
+	}
+
+	data := jobTemplate.JobTemplateData
+	if data.JobDriver == nil || data.JobDriver.SparkSubmitJobDriver == nil {
+		unsupported = append(unsupported, "jobDriver other than sparkSubmitJobDriver")
+	}
+
+	overrides := data.ConfigurationOverrides
+	if overrides == nil {
+		return unsupported
+	}
+	for i, appConfig := range overrides.ApplicationConfiguration {
+		if len(appConfig.Configurations) > 0 {
+			unsupported = append(unsupported, fmt.Sprintf("applicationConfiguration[%d].configurations", i))
+		}
+	}
+	if monitoring := overrides.MonitoringConfiguration; monitoring != nil && monitoring.S3MonitoringConfiguration != nil {
+		unsupported = append(unsupported, "monitoring.s3MonitoringConfiguration")
+	}
+
+	return unsupported
+}
@@ -0,0 +1,183 @@
+package awsutils_test
+
+import (
+	"testing"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImportConfig is a job template config using every field the import maps back.
+func testImportConfig() template.JobTemplateConfig {
+	return template.JobTemplateConfig{
+		Name:                "imported-job",
+		ExecutionRoleArn:    "arn:aws:iam::123456789012:role/EMRExecutionRole",
+		ReleaseLabel:        "emr-6.4.0-latest",
+		EntryPoint:          "s3://bucket/script.py",
+		EntryPointArguments: []string{"--input", "s3://bucket/input"},
+		Tags:                map[string]string{"Owner": "team-x"},
+		SparkSubmitParameters: template.SparkSubmitParameters{
+			Master: "yarn", DeployMode: "cluster", Class: "org.example.Main",
+			Conf: []string{"spark.executor.instances=${Executors}"}, Packages: "org.example:lib:1.0",
+		},
+		PersistentAppUI: "ENABLED",
+		LogGroupName:    "/aws/emr-containers/jobs",
+		ParameterConfiguration: map[string]template.TemplateParameterConfiguration{
+			"Executors": {DefaultValue: aws.String("2"), Type: types.TemplateParameterDataTypeNumber},
+		},
+		ApplicationConfigurations: []template.ApplicationConfiguration{
+			{Classification: "spark-defaults", Properties: map[string]string{"spark.dynamicAllocation.enabled": "false"}},
+		},
+	}
+}
+
+// testDeployedTemplate returns the job template PrepareJobTemplateInput creates from a config.
+func testDeployedTemplate(t *testing.T, jobConfig template.JobTemplateConfig) types.JobTemplate {
+	t.Helper()
+	input, err := awsutils.PrepareJobTemplateInput(jobConfig, &awsutils.RealParameterConfigurator{}, &awsutils.RealSparkSubmitCommandBuilder{}, &awsutils.RealClientTokenGenerator{})
+	require.NoError(t, err)
+
+	return types.JobTemplate{
+		Id:              aws.String("tmpl-1"),
+		Name:            input.Name,
+		Tags:            input.Tags,
+		JobTemplateData: input.JobTemplateData,
+	}
+}
+
+func TestImportJobTemplate_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	got, err := awsutils.ImportJobTemplate(testDeployedTemplate(t, testImportConfig()))
+
+	require.NoError(t, err)
+	assert.Equal(t, testImportConfig(), got)
+}
+
+func TestImportJobTemplate_CreatedElsewhere(t *testing.T) {
+	t.Parallel()
+	deployed := testDeployedTemplate(t, testImportConfig())
+	deployed.Tags = nil
+	delete(deployed.JobTemplateData.JobTags, "Name")
+	deployed.JobTemplateData.ConfigurationOverrides.MonitoringConfiguration.CloudWatchMonitoringConfiguration.LogStreamNamePrefix = nil
+
+	got, err := awsutils.ImportJobTemplate(deployed)
+
+	require.NoError(t, err)
+	assert.Equal(t, testImportConfig(), got)
+}
+
+func TestImportJobTemplate_HandWrittenSparkSubmitParameters(t *testing.T) {
+	t.Parallel()
+	deployed := testDeployedTemplate(t, testImportConfig())
+	deployed.JobTemplateData.JobDriver.SparkSubmitJobDriver.SparkSubmitParameters = aws.String(
+		`--class org.example.Main --num-executors 4 --conf spark.executor.instances=${Executors} --master=yarn --name 'nightly etl' --packages org.example:lib:1.0  --deploy-mode cluster --verbose`)
+
+	got, err := awsutils.ImportJobTemplate(deployed)
+
+	require.NoError(t, err)
+	want := testImportConfig()
+	want.SparkSubmitParameters.ExtraArgs = []string{"--num-executors", "4", "--name", "'nightly etl'", "--verbose"}
+	assert.Equal(t, want, got)
+}
+
+func TestImportJobTemplate_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		modify  func(data *types.JobTemplateData)
+		wantErr string
+	}{
+		{
+			name: "S3 Monitoring",
+			modify: func(data *types.JobTemplateData) {
+				data.ConfigurationOverrides.MonitoringConfiguration.S3MonitoringConfiguration = &types.ParametricS3MonitoringConfiguration{LogUri: aws.String("s3://logs")}
+			},
+			wantErr: "job template 'imported-job' uses settings the YAML cannot express: monitoring.s3MonitoringConfiguration",
+		},
+		{
+			name: "Missing Required Spark Submit Parameter",
+			modify: func(data *types.JobTemplateData) {
+				data.JobDriver.SparkSubmitJobDriver.SparkSubmitParameters = aws.String("--master yarn --deploy-mode cluster --class org.example.Main")
+			},
+			wantErr: "job template 'imported-job' cannot be rebuilt: sparkSubmitParameters configuration block failed: missing required parameter: packages",
+		},
+		{
+			name: "Different Log Stream Prefix",
+			modify: func(data *types.JobTemplateData) {
+				data.ConfigurationOverrides.MonitoringConfiguration.CloudWatchMonitoringConfiguration.LogStreamNamePrefix = aws.String("custom")
+			},
+			wantErr: "job template 'imported-job' would not round-trip, these fields differ: monitoring.logStreamNamePrefix",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			deployed := testDeployedTemplate(t, testImportConfig())
+			tt.modify(deployed.JobTemplateData)
+
+			_, err := awsutils.ImportJobTemplate(deployed)
+
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestImportJobTemplate_TemplateSettings(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		modify  func(jobTemplate *types.JobTemplate)
+		wantErr string
+	}{
+		{
+			name: "KMS Key",
+			modify: func(jobTemplate *types.JobTemplate) {
+				jobTemplate.KmsKeyArn = aws.String("arn:aws:kms:eu-west-1:123456789012:key/abcd")
+			},
+			wantErr: "job template 'imported-job' uses settings the YAML cannot express: kmsKeyArn",
+		},
+		{
+			name: "Template Tag Differs From Job Tag",
+			modify: func(jobTemplate *types.JobTemplate) {
+				jobTemplate.Tags = map[string]string{"Name": "imported-job", "Owner": "team-y"}
+			},
+			wantErr: "job template 'imported-job' would not round-trip, these fields differ: tags.Owner",
+		},
+		{
+			name: "Template Tag Without Job Tag",
+			modify: func(jobTemplate *types.JobTemplate) {
+				jobTemplate.Tags = map[string]string{"Name": "imported-job", "Owner": "team-x", "CostCenter": "1234"}
+			},
+			wantErr: "job template 'imported-job' would not round-trip, these fields differ: tags.CostCenter",
+		},
+		{
+			// apply tags templates that failed their smoke test, the tag is not part of the config.
+			name: "Status Tag",
+			modify: func(jobTemplate *types.JobTemplate) {
+				jobTemplate.Tags = map[string]string{"Name": "imported-job", "Owner": "team-x", awsutils.StatusTag: awsutils.StatusFailedSmoke}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			deployed := testDeployedTemplate(t, testImportConfig())
+			tt.modify(&deployed)
+
+			got, err := awsutils.ImportJobTemplate(deployed)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testImportConfig(), got)
+		})
+	}
+}
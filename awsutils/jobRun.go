@@ -0,0 +1,81 @@
+package awsutils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+)
+
+// JobRunInput describes a job run started from a deployed job template.
+type JobRunInput struct {
+	Name             string
+	VirtualClusterID string
+	JobTemplateID    string
+	Parameters       map[string]string
+}
+
+// CheckJobRunParameters checks parameter overrides against the parameter configuration of a job
+// template: every override must be declared, NUMBER values must be numbers, and parameters without
+// a default value must be given. All problems are reported together.
+func CheckJobRunParameters(paramConfig map[string]types.TemplateParameterConfiguration, overrides map[string]string) error {
+	var problems []string
+
+	for _, name := range sortedKeys(overrides) {
+		param, ok := paramConfig[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("parameter %q is not declared by the job template", name))
+
+			continue
+		}
+		if param.Type == types.TemplateParameterDataTypeNumber {
+			if _, err := strconv.ParseFloat(overrides[name], 64); err != nil {
+				problems = append(problems, fmt.Sprintf("parameter %q must be a NUMBER, got %q", name, overrides[name]))
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(paramConfig) {
+		if _, ok := overrides[name]; !ok && paramConfig[name].DefaultValue == nil {
+			problems = append(problems, fmt.Sprintf("parameter %q has no default value and must be set", name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid job run parameters: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// StartJobRun starts a job run from a job template and returns the job run ID.
+// A random client token makes retries of the same call idempotent.
+func StartJobRun(ctx context.Context, client EMRC, input JobRunInput) (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate job run client token: %w", err)
+	}
+
+	params := &emrcontainers.StartJobRunInput{
+		Name:             aws.String(input.Name),
+		VirtualClusterId: aws.String(input.VirtualClusterID),
+		JobTemplateId:    aws.String(input.JobTemplateID),
+		ClientToken:      aws.String(hex.EncodeToString(token)),
+	}
+	if len(input.Parameters) > 0 {
+		params.JobTemplateParameters = input.Parameters
+	}
+
+	resp, err := client.StartJobRun(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to start job run: %w", err)
+	}
+
+	return aws.ToString(resp.Id), nil
+}
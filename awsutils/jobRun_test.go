@@ -0,0 +1,88 @@
+package awsutils_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckJobRunParameters(t *testing.T) {
+	t.Parallel()
+	paramConfig := map[string]types.TemplateParameterConfiguration{
+		"MaxExecutors": {Type: types.TemplateParameterDataTypeNumber, DefaultValue: aws.String("10")},
+		"RunDate":      {Type: types.TemplateParameterDataTypeString},
+	}
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		wantErr   string
+	}{
+		{name: "valid", overrides: map[string]string{"RunDate": "2024-01-01", "MaxExecutors": "2.5"}},
+		{name: "default used", overrides: map[string]string{"RunDate": "2024-01-01"}},
+		{
+			name:      "all problems reported",
+			overrides: map[string]string{"MaxExecutors": "many", "Unknown": "x"},
+			wantErr: `invalid job run parameters: parameter "MaxExecutors" must be a NUMBER, got "many"; ` +
+				`parameter "Unknown" is not declared by the job template; parameter "RunDate" has no default value and must be set`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := awsutils.CheckJobRunParameters(paramConfig, tt.overrides)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestStartJobRun(t *testing.T) {
+	t.Parallel()
+	var got *emrcontainers.StartJobRunInput
+	mockClient := &MockEMRCclient{
+		StartJobRunFunc: func(ctx context.Context, params *emrcontainers.StartJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.StartJobRunOutput, error) {
+			got = params
+
+			return &emrcontainers.StartJobRunOutput{Id: aws.String("run-123")}, nil
+		},
+	}
+
+	jobRunID, err := awsutils.StartJobRun(context.Background(), mockClient, awsutils.JobRunInput{
+		Name:             "custom-job",
+		VirtualClusterID: "vc-1",
+		JobTemplateID:    "jt-123",
+		Parameters:       map[string]string{"RunDate": "2024-01-01"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "run-123", jobRunID)
+	assert.Equal(t, "custom-job", aws.ToString(got.Name))
+	assert.Equal(t, "vc-1", aws.ToString(got.VirtualClusterId))
+	assert.Equal(t, "jt-123", aws.ToString(got.JobTemplateId))
+	assert.Equal(t, map[string]string{"RunDate": "2024-01-01"}, got.JobTemplateParameters)
+	assert.Len(t, aws.ToString(got.ClientToken), 32)
+}
+
+func TestStartJobRun_Error(t *testing.T) {
+	t.Parallel()
+	mockClient := &MockEMRCclient{
+		StartJobRunFunc: func(ctx context.Context, params *emrcontainers.StartJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.StartJobRunOutput, error) {
+			return nil, errors.New("access denied")
+		},
+	}
+
+	_, err := awsutils.StartJobRun(context.Background(), mockClient, awsutils.JobRunInput{Name: "custom-job", VirtualClusterID: "vc-1", JobTemplateID: "jt-123"})
+
+	require.ErrorContains(t, err, "failed to start job run")
+}
@@ -0,0 +1,69 @@
+package awsutils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+)
+
+// PruneOptions controls which superseded job templates may be deleted.
+type PruneOptions struct {
+	// Keep is the number of most recent templates kept per name.
+	Keep int
+	// MinAge protects templates created less than MinAge ago, as in-flight job runs may still use them.
+	MinAge time.Duration
+	// Now is the reference time for MinAge.
+	Now time.Time
+}
+
+// GroupJobTemplatesByName groups job templates by the "Name" tag set by PrepareJobTemplateInput,
+// newest first within each group.
+func GroupJobTemplatesByName(templates []types.JobTemplate) map[string][]types.JobTemplate {
+	groups := make(map[string][]types.JobTemplate)
+	for _, jobTemplate := range templates {
+		name := JobTemplateName(jobTemplate)
+		groups[name] = append(groups[name], jobTemplate)
+	}
+
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool {
+			return aws.ToTime(group[i].CreatedAt).After(aws.ToTime(group[j].CreatedAt))
+		})
+	}
+
+	return groups
+}
+
+// SelectJobTemplatesToPrune returns the templates of a newest-first group that can be deleted.
+// The Keep most recent templates, the protected IDs and templates younger than MinAge are kept.
+func SelectJobTemplatesToPrune(group []types.JobTemplate, protected map[string]bool, opts PruneOptions) []types.JobTemplate {
+	var prune []types.JobTemplate
+	for i, jobTemplate := range group {
+		switch {
+		case i < opts.Keep:
+		case protected[aws.ToString(jobTemplate.Id)]:
+		case opts.Now.Sub(aws.ToTime(jobTemplate.CreatedAt)) < opts.MinAge:
+		default:
+			prune = append(prune, jobTemplate)
+		}
+	}
+
+	return prune
+}
+
+// DeleteJobTemplate deletes the job template with the given ID.
+func DeleteJobTemplate(ctx context.Context, client EMRC, jobTemplateID string) error {
+	_, err := client.DeleteJobTemplate(ctx, &emrcontainers.DeleteJobTemplateInput{
+		Id: &jobTemplateID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete job template: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package awsutils_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPruneTemplate(id, name string, createdAt time.Time) types.JobTemplate {
+	return types.JobTemplate{
+		Id:        aws.String(id),
+		Name:      aws.String(name),
+		Tags:      map[string]string{"Name": name},
+		CreatedAt: aws.Time(createdAt),
+	}
+}
+
+func templateIDs(templates []types.JobTemplate) []string {
+	ids := make([]string, 0, len(templates))
+	for _, jobTemplate := range templates {
+		ids = append(ids, aws.ToString(jobTemplate.Id))
+	}
+
+	return ids
+}
+
+func TestGroupJobTemplatesByName(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	groups := awsutils.GroupJobTemplatesByName([]types.JobTemplate{
+		testPruneTemplate("job-1", "job", now.Add(-2*time.Hour)),
+		testPruneTemplate("other-1", "other", now),
+		testPruneTemplate("job-2", "job", now),
+	})
+
+	require.Len(t, groups, 2)
+	assert.Equal(t, []string{"job-2", "job-1"}, templateIDs(groups["job"]))
+	assert.Equal(t, []string{"other-1"}, templateIDs(groups["other"]))
+}
+
+func TestSelectJobTemplatesToPrune(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	group := []types.JobTemplate{
+		testPruneTemplate("newest", "job", now.Add(-time.Hour)),
+		testPruneTemplate("young", "job", now.Add(-2*time.Hour)),
+		testPruneTemplate("referenced", "job", now.Add(-72*time.Hour)),
+		testPruneTemplate("old", "job", now.Add(-96*time.Hour)),
+		testPruneTemplate("oldest", "job", now.Add(-120*time.Hour)),
+	}
+	protected := map[string]bool{"referenced": true}
+
+	prune := awsutils.SelectJobTemplatesToPrune(group, protected, awsutils.PruneOptions{
+		Keep:   1,
+		MinAge: 24 * time.Hour,
+		Now:    now,
+	})
+
+	assert.Equal(t, []string{"old", "oldest"}, templateIDs(prune))
+}
+
+func TestSelectJobTemplatesToPrune_KeepAll(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	group := []types.JobTemplate{
+		testPruneTemplate("newest", "job", now.Add(-96*time.Hour)),
+		testPruneTemplate("oldest", "job", now.Add(-120*time.Hour)),
+	}
+
+	prune := awsutils.SelectJobTemplatesToPrune(group, nil, awsutils.PruneOptions{Keep: 2, Now: now})
+
+	assert.Empty(t, prune)
+}
+
+func TestDeleteJobTemplate(t *testing.T) {
+	t.Parallel()
+	var deleted string
+	mockClient := &MockEMRCclient{
+		DeleteJobTemplateFunc: func(ctx context.Context, params *emrcontainers.DeleteJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DeleteJobTemplateOutput, error) {
+			deleted = aws.ToString(params.Id)
+
+			return &emrcontainers.DeleteJobTemplateOutput{}, nil
+		},
+	}
+
+	require.NoError(t, awsutils.DeleteJobTemplate(context.Background(), mockClient, "template-id"))
+	assert.Equal(t, "template-id", deleted)
+
+	mockClient.DeleteJobTemplateFunc = func(ctx context.Context, params *emrcontainers.DeleteJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DeleteJobTemplateOutput, error) {
+		return nil, fmt.Errorf("failed success")
+	}
+	assert.ErrorContains(t, awsutils.DeleteJobTemplate(context.Background(), mockClient, "template-id"), "failed to delete job template")
+}
@@ -0,0 +1,240 @@
+package awsutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/smithy-go"
+)
+
+// retryableErrorCodes lists the API error codes of throttled or transient failures.
+var retryableErrorCodes = map[string]bool{
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"TooManyRequestsException":               true,
+	"RequestLimitExceeded":                   true,
+	"TooManyUpdates":                         true,
+	"ProvisionedThroughputExceededException": true,
+	"InternalServerException":                true,
+	"InternalServerError":                    true,
+	"InternalError":                          true,
+	"ServiceUnavailable":                     true,
+	"ServiceUnavailableException":            true,
+}
+
+// RetryPolicy retries throttled and transient AWS errors with jittered exponential backoff.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of calls, including the first one.
+	MaxAttempts int
+	// BaseDelay is the backoff ceiling before the first retry; it doubles on every retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff ceiling.
+	MaxDelay time.Duration
+	// Jitter picks the actual delay below the ceiling, defaults to a uniformly random delay.
+	Jitter func(ceiling time.Duration) time.Duration
+	// OnRetry is called before sleeping, for logging.
+	OnRetry func(attempt int, delay time.Duration, err error)
+}
+
+// DefaultRetryPolicy returns the policy used when nothing is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 5, BaseDelay: 200 * time.Millisecond, MaxDelay: 20 * time.Second}
+}
+
+// IsRetryable reports whether an AWS error is worth retrying: throttling, 429 and 5xx
+// responses are transient, everything else including context cancellation is terminal.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && retryableErrorCodes[apiErr.ErrorCode()] {
+		return true
+	}
+
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		status := responseErr.HTTPStatusCode()
+
+		return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+// backoff returns the delay ceiling before the given retry, starting at 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.BaseDelay
+	for i := 1; i < retry && ceiling < p.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	if p.MaxDelay > 0 && ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+
+	return ceiling
+}
+
+// Do calls fn until it succeeds, fails with a terminal error or the attempts run out.
+// It never sleeps past the context deadline: when the next delay would not fit, the last error is returned.
+func (p RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	jitter := p.Jitter
+	if jitter == nil {
+		jitter = func(ceiling time.Duration) time.Duration {
+			if ceiling <= 0 {
+				return 0
+			}
+
+			return rand.N(ceiling + 1)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !IsRetryable(err) {
+			return err
+		}
+		if attempt >= p.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		delay := jitter(p.backoff(attempt))
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return fmt.Errorf("not retrying, context deadline is too close: %w", err)
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, delay, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// retryCall runs a single client call under the retry policy.
+func retryCall[T any](ctx context.Context, policy RetryPolicy, call func(ctx context.Context) (T, error)) (T, error) {
+	var out T
+	err := policy.Do(ctx, func(ctx context.Context) error {
+		var err error
+		out, err = call(ctx)
+
+		return err
+	})
+
+	return out, err
+}
+
+// RetryingEMRCClient wraps an EMRC client so every call follows the retry policy.
+// Retrying CreateJobTemplate is safe because the client token makes it idempotent.
+type RetryingEMRCClient struct {
+	Client EMRC
+	Policy RetryPolicy
+}
+
+func (r *RetryingEMRCClient) DescribeJobTemplate(ctx context.Context, params *emrcontainers.DescribeJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobTemplateOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*emrcontainers.DescribeJobTemplateOutput, error) {
+		return r.Client.DescribeJobTemplate(ctx, params, optFns...)
+	})
+}
+
+func (r *RetryingEMRCClient) CreateJobTemplate(ctx context.Context, params *emrcontainers.CreateJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CreateJobTemplateOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*emrcontainers.CreateJobTemplateOutput, error) {
+		return r.Client.CreateJobTemplate(ctx, params, optFns...)
+	})
+}
+
+func (r *RetryingEMRCClient) ListJobTemplates(ctx context.Context, params *emrcontainers.ListJobTemplatesInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.ListJobTemplatesOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*emrcontainers.ListJobTemplatesOutput, error) {
+		return r.Client.ListJobTemplates(ctx, params, optFns...)
+	})
+}
+
+func (r *RetryingEMRCClient) DeleteJobTemplate(ctx context.Context, params *emrcontainers.DeleteJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DeleteJobTemplateOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*emrcontainers.DeleteJobTemplateOutput, error) {
+		return r.Client.DeleteJobTemplate(ctx, params, optFns...)
+	})
+}
+
+// StartJobRun is retried with the same client token, so a retried call does not start a second run.
+func (r *RetryingEMRCClient) StartJobRun(ctx context.Context, params *emrcontainers.StartJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.StartJobRunOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*emrcontainers.StartJobRunOutput, error) {
+		return r.Client.StartJobRun(ctx, params, optFns...)
+	})
+}
+
+func (r *RetryingEMRCClient) DescribeJobRun(ctx context.Context, params *emrcontainers.DescribeJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobRunOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*emrcontainers.DescribeJobRunOutput, error) {
+		return r.Client.DescribeJobRun(ctx, params, optFns...)
+	})
+}
+
+func (r *RetryingEMRCClient) CancelJobRun(ctx context.Context, params *emrcontainers.CancelJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CancelJobRunOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*emrcontainers.CancelJobRunOutput, error) {
+		return r.Client.CancelJobRun(ctx, params, optFns...)
+	})
+}
+
+func (r *RetryingEMRCClient) TagResource(ctx context.Context, params *emrcontainers.TagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.TagResourceOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*emrcontainers.TagResourceOutput, error) {
+		return r.Client.TagResource(ctx, params, optFns...)
+	})
+}
+
+func (r *RetryingEMRCClient) UntagResource(ctx context.Context, params *emrcontainers.UntagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.UntagResourceOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*emrcontainers.UntagResourceOutput, error) {
+		return r.Client.UntagResource(ctx, params, optFns...)
+	})
+}
+
+// RetryingSSMClient wraps an SSM client so every call follows the retry policy.
+type RetryingSSMClient struct {
+	Client SSM
+	Policy RetryPolicy
+}
+
+func (r *RetryingSSMClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*ssm.PutParameterOutput, error) {
+		return r.Client.PutParameter(ctx, params, optFns...)
+	})
+}
+
+func (r *RetryingSSMClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*ssm.GetParameterOutput, error) {
+		return r.Client.GetParameter(ctx, params, optFns...)
+	})
+}
+
+func (r *RetryingSSMClient) GetParameterHistory(ctx context.Context, params *ssm.GetParameterHistoryInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*ssm.GetParameterHistoryOutput, error) {
+		return r.Client.GetParameterHistory(ctx, params, optFns...)
+	})
+}
+
+func (r *RetryingSSMClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	return retryCall(ctx, r.Policy, func(ctx context.Context) (*ssm.DeleteParameterOutput, error) {
+		return r.Client.DeleteParameter(ctx, params, optFns...)
+	})
+}
+
+// WithRetries returns a copy of the clients whose calls follow the retry policy.
+func WithRetries(clients *AWSClients, policy RetryPolicy) *AWSClients {
+	return &AWSClients{
+		EMRContainers: &RetryingEMRCClient{Client: clients.EMRContainers, Policy: policy},
+		SSM:           &RetryingSSMClient{Client: clients.SSM, Policy: policy},
+	}
+}
@@ -0,0 +1,183 @@
+package awsutils_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRetryPolicy retries without jitter so the delays are predictable.
+func testRetryPolicy(delays *[]time.Duration) awsutils.RetryPolicy {
+	return awsutils.RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    3 * time.Millisecond,
+		Jitter:      func(ceiling time.Duration) time.Duration { return ceiling },
+		OnRetry: func(_ int, delay time.Duration, _ error) {
+			if delays != nil {
+				*delays = append(*delays, delay)
+			}
+		},
+	}
+}
+
+func httpStatusError(status int) error {
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      errors.New("http error"),
+		},
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"throttling", &smithy.GenericAPIError{Code: "ThrottlingException"}, true},
+		{"ssm too many updates", fmt.Errorf("ssm update failed err: %w", &smithy.GenericAPIError{Code: "TooManyUpdates"}), true},
+		{"emr internal server", &types.InternalServerException{Message: aws.String("boom")}, true},
+		{"validation", &types.ValidationException{Message: aws.String("bad")}, false},
+		{"429", httpStatusError(http.StatusTooManyRequests), true},
+		{"503", httpStatusError(http.StatusServiceUnavailable), true},
+		{"400", httpStatusError(http.StatusBadRequest), false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"plain", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, awsutils.IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	t.Parallel()
+	throttled := &smithy.GenericAPIError{Code: "ThrottlingException"}
+
+	t.Run("succeeds after transient errors", func(t *testing.T) {
+		t.Parallel()
+		var delays []time.Duration
+		calls := 0
+		err := testRetryPolicy(&delays).Do(context.Background(), func(context.Context) error {
+			calls++
+			if calls < 4 {
+				return throttled
+			}
+
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, calls)
+		assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}, delays)
+	})
+
+	t.Run("terminal error is not retried", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		terminal := &types.ValidationException{Message: aws.String("bad")}
+		err := testRetryPolicy(nil).Do(context.Background(), func(context.Context) error {
+			calls++
+
+			return terminal
+		})
+
+		require.ErrorIs(t, err, terminal)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		err := testRetryPolicy(nil).Do(context.Background(), func(context.Context) error {
+			calls++
+
+			return throttled
+		})
+
+		require.ErrorIs(t, err, throttled)
+		require.ErrorContains(t, err, "giving up after 4 attempts")
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("respects the context deadline", func(t *testing.T) {
+		t.Parallel()
+		policy := testRetryPolicy(nil)
+		policy.BaseDelay, policy.MaxDelay = time.Hour, time.Hour
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		calls := 0
+		start := time.Now()
+		err := policy.Do(ctx, func(context.Context) error {
+			calls++
+
+			return throttled
+		})
+
+		require.ErrorIs(t, err, throttled)
+		require.ErrorContains(t, err, "context deadline is too close")
+		assert.Equal(t, 1, calls)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestRetryingClients(t *testing.T) {
+	t.Parallel()
+	putCalls := 0
+	createCalls := 0
+	clients := awsutils.WithRetries(&awsutils.AWSClients{
+		SSM: &MockSSMClient{
+			GetParameterFunc: func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+				return nil, &ssmtypes.ParameterNotFound{}
+			},
+			PutParameterFunc: func(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+				putCalls++
+				if putCalls == 1 {
+					return nil, &smithy.GenericAPIError{Code: "TooManyUpdates"}
+				}
+
+				return &ssm.PutParameterOutput{}, nil
+			},
+		},
+		EMRContainers: &MockEMRCclient{
+			CreateJobTemplateFunc: func(ctx context.Context, params *emrcontainers.CreateJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CreateJobTemplateOutput, error) {
+				createCalls++
+				if createCalls < 3 {
+					return nil, httpStatusError(http.StatusBadGateway)
+				}
+
+				return &emrcontainers.CreateJobTemplateOutput{Id: aws.String("jt-123")}, nil
+			},
+		},
+	}, testRetryPolicy(nil))
+
+	require.NoError(t, awsutils.UpdateSSMParameter(context.Background(), clients.SSM, "/emr/job", "jt-123"))
+	assert.Equal(t, 2, putCalls)
+
+	id, err := awsutils.CreateJobTemplate(context.Background(), clients.EMRContainers, &emrcontainers.CreateJobTemplateInput{Name: aws.String("job")})
+	require.NoError(t, err)
+	assert.Equal(t, "jt-123", id)
+	assert.Equal(t, 3, createCalls)
+}
@@ -0,0 +1,61 @@
+package awsutils
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+)
+
+// RollbackCandidates returns the job template IDs that can be rolled back to, most recent first.
+// Values from the SSM parameter history come first, followed by older templates sharing the
+// same "Name" tag. Templates that failed their smoke test are never candidates. The group is
+// expected newest first, as returned by GroupJobTemplatesByName.
+//
+// Only the history from before the current template was first deployed counts: after a rollback
+// from B to A the history reads A, B, A and B is the template that was rolled away from, not an
+// earlier version. For the same reason templates created after the current one are skipped.
+func RollbackCandidates(currentID string, history []string, group []types.JobTemplate) []string {
+	seen := map[string]bool{currentID: true, "": true}
+	for _, jobTemplate := range group {
+		if IsFailedSmokeTest(jobTemplate) {
+			seen[aws.ToString(jobTemplate.Id)] = true
+		}
+	}
+
+	// Only templates older than the current one are earlier versions.
+	var current *types.JobTemplate
+	for i := range group {
+		if aws.ToString(group[i].Id) == currentID {
+			current = &group[i]
+		}
+	}
+	for _, jobTemplate := range group {
+		if current != nil && !aws.ToTime(jobTemplate.CreatedAt).Before(aws.ToTime(current.CreatedAt)) {
+			seen[aws.ToString(jobTemplate.Id)] = true
+		}
+	}
+
+	var candidates []string
+	add := func(jobTemplateID string) {
+		if !seen[jobTemplateID] {
+			seen[jobTemplateID] = true
+			candidates = append(candidates, jobTemplateID)
+		}
+	}
+
+	// The history is newest first, its last occurrence of the current value is the first deployment.
+	firstDeployed := -1
+	for i, jobTemplateID := range history {
+		if jobTemplateID == currentID {
+			firstDeployed = i
+		}
+	}
+	for _, jobTemplateID := range history[firstDeployed+1:] {
+		add(jobTemplateID)
+	}
+
+	for _, jobTemplate := range group {
+		add(aws.ToString(jobTemplate.Id))
+	}
+
+	return candidates
+}
@@ -0,0 +1,68 @@
+package awsutils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollbackCandidates(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	group := []types.JobTemplate{
+		testPruneTemplate("newer", "job", now),
+		testPruneTemplate("current", "job", now.Add(-time.Hour)),
+		testPruneTemplate("previous", "job", now.Add(-2*time.Hour)),
+		testPruneTemplate("oldest", "job", now.Add(-3*time.Hour)),
+	}
+	history := []string{"current", "current", "previous", "from-history", "previous"}
+
+	candidates := awsutils.RollbackCandidates("current", history, group)
+
+	assert.Equal(t, []string{"previous", "from-history", "oldest"}, candidates)
+}
+
+func TestRollbackCandidates_AfterRollback(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	group := []types.JobTemplate{
+		testPruneTemplate("bad", "job", now),
+		testPruneTemplate("good", "job", now.Add(-time.Hour)),
+		testPruneTemplate("older", "job", now.Add(-2*time.Hour)),
+	}
+	// "bad" was deployed and rolled back to "good", newest first.
+	history := []string{"good", "bad", "good", "older"}
+
+	assert.Equal(t, []string{"older"}, awsutils.RollbackCandidates("good", history, group))
+	// A template newer than the current one is never a rollback target, even from the history.
+	assert.Equal(t, []string{"older"}, awsutils.RollbackCandidates("good", []string{"good", "bad"}, group))
+}
+
+func TestRollbackCandidates_NoHistory(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	group := []types.JobTemplate{
+		testPruneTemplate("current", "job", now),
+		testPruneTemplate("previous", "job", now.Add(-time.Hour)),
+	}
+
+	assert.Equal(t, []string{"previous"}, awsutils.RollbackCandidates("current", nil, group))
+	assert.Empty(t, awsutils.RollbackCandidates("previous", nil, group[1:]))
+}
+
+func TestRollbackCandidates_SkipsFailedSmokeTest(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	failed := testPruneTemplate("failed", "job", now.Add(-time.Hour))
+	failed.Tags[awsutils.StatusTag] = awsutils.StatusFailedSmoke
+	group := []types.JobTemplate{
+		testPruneTemplate("current", "job", now),
+		failed,
+		testPruneTemplate("previous", "job", now.Add(-2*time.Hour)),
+	}
+
+	assert.Equal(t, []string{"previous"}, awsutils.RollbackCandidates("current", []string{"failed", "current"}, group))
+}
@@ -0,0 +1,207 @@
+package awsutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest file formats.
+const (
+	ManifestFormatJSON = "json"
+	ManifestFormatYAML = "yaml"
+)
+
+// TemplateIDSink publishes the ID of a created job template so that consumers can find it.
+type TemplateIDSink interface {
+	// Publish records jobTemplateID as the current ID of the named job template.
+	Publish(ctx context.Context, name, jobTemplateID string) error
+	// String describes the sink in logs.
+	String() string
+}
+
+// SSMSink publishes the job template ID to SSM parameters through a prepared transaction,
+// so a later failure can restore the previous values.
+type SSMSink struct {
+	Transaction *SSMTransaction
+}
+
+// Publish writes the ID to every prepared SSM parameter.
+func (s *SSMSink) Publish(ctx context.Context, _, jobTemplateID string) error {
+	return s.Transaction.Commit(ctx, jobTemplateID)
+}
+
+func (s *SSMSink) String() string {
+	names := make([]string, 0, len(s.Transaction.previous))
+	for _, previous := range s.Transaction.previous {
+		names = append(names, previous.Name)
+	}
+
+	return "SSM parameters " + strings.Join(names, ", ")
+}
+
+// ManifestSink keeps a JSON or YAML file mapping job template names to their current IDs.
+// Entries of other job templates are preserved, and publishes are serialized so templates
+// processed in parallel can share the file.
+type ManifestSink struct {
+	Path   string
+	Format string
+
+	mu sync.Mutex
+}
+
+// Publish sets the entry of the job template and rewrites the manifest atomically.
+func (s *ManifestSink) Publish(_ context.Context, name, jobTemplateID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	manifest := make(map[string]string)
+	data, err := os.ReadFile(s.Path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read manifest %s: %w", s.Path, err)
+	case len(strings.TrimSpace(string(data))) > 0:
+		if err := s.unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("failed to parse manifest %s: %w", s.Path, err)
+		}
+	}
+
+	manifest[name] = jobTemplateID
+	data, err = s.marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest %s: %w", s.Path, err)
+	}
+
+	return writeFileAtomic(s.Path, data)
+}
+
+func (s *ManifestSink) String() string {
+	return fmt.Sprintf("%s manifest %s", s.Format, s.Path)
+}
+
+func (s *ManifestSink) unmarshal(data []byte, manifest *map[string]string) error {
+	if s.Format == ManifestFormatYAML {
+		return yaml.Unmarshal(data, manifest)
+	}
+
+	return json.Unmarshal(data, manifest)
+}
+
+func (s *ManifestSink) marshal(manifest map[string]string) ([]byte, error) {
+	if s.Format == ManifestFormatYAML {
+		return yaml.Marshal(manifest)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+
+	return append(data, '\n'), err
+}
+
+// writeFileAtomic replaces a file through a temporary file in the same directory,
+// so readers never observe a partially written manifest. The file keeps its mode,
+// a new file is created readable by everyone like the dotenv files.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	// CreateTemp creates the file with mode 0600, which the rename would keep.
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// DotenvSink appends KEY=value lines to a file, the format of dotenv files and of
+// GitHub Actions' GITHUB_OUTPUT and GITHUB_ENV files.
+type DotenvSink struct {
+	Path string
+	Key  string
+
+	// mu is shared by every sink appending to the same file.
+	mu *sync.Mutex
+}
+
+// NewDotenvSink returns a sink appending to path under key, serialized with mu.
+func NewDotenvSink(path, key string, mu *sync.Mutex) *DotenvSink {
+	return &DotenvSink{Path: path, Key: key, mu: mu}
+}
+
+// Publish appends the key with the job template ID.
+func (s *DotenvSink) Publish(_ context.Context, _, jobTemplateID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.Path, err)
+	}
+
+	_, err = fmt.Fprintf(file, "%s=%s\n", s.Key, jobTemplateID)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to append to %s: %w", s.Path, err)
+	}
+
+	return nil
+}
+
+func (s *DotenvSink) String() string {
+	return fmt.Sprintf("dotenv %s (%s)", s.Path, s.Key)
+}
+
+// WriterSink prints "name=id" lines, typically to stdout.
+type WriterSink struct {
+	W io.Writer
+
+	mu sync.Mutex
+}
+
+// Publish prints the job template name and ID.
+func (s *WriterSink) Publish(_ context.Context, name, jobTemplateID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := fmt.Fprintf(s.W, "%s=%s\n", name, jobTemplateID); err != nil {
+		return fmt.Errorf("failed to print job template ID: %w", err)
+	}
+
+	return nil
+}
+
+func (s *WriterSink) String() string {
+	return "stdout"
+}
@@ -0,0 +1,112 @@
+package awsutils_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestSink_JSON(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "templates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"other-job": "jt-other"}`), 0o600))
+	require.NoError(t, os.Chmod(path, 0o640))
+
+	sink := &awsutils.ManifestSink{Path: path, Format: awsutils.ManifestFormatJSON}
+	require.NoError(t, sink.Publish(context.Background(), "custom-job", "jt-old"))
+	require.NoError(t, sink.Publish(context.Background(), "custom-job", "jt-123"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"custom-job\": \"jt-123\",\n  \"other-job\": \"jt-other\"\n}\n", string(data))
+	assert.Equal(t, "json manifest "+path, sink.String())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm(), "the existing mode must be kept")
+}
+
+func TestManifestSink_YAMLConcurrent(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "templates.yaml")
+	sink := &awsutils.ManifestSink{Path: path, Format: awsutils.ManifestFormatYAML}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, sink.Publish(context.Background(), fmt.Sprintf("job-%d", i), fmt.Sprintf("jt-%d", i)))
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assert.Contains(t, string(data), fmt.Sprintf("job-%d: jt-%d\n", i, i))
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files must be cleaned up")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+}
+
+func TestManifestSink_InvalidFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "templates.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	sink := &awsutils.ManifestSink{Path: path, Format: awsutils.ManifestFormatJSON}
+	require.ErrorContains(t, sink.Publish(context.Background(), "custom-job", "jt-123"), "failed to parse manifest")
+}
+
+func TestDotenvSink(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "github_output")
+	require.NoError(t, os.WriteFile(path, []byte("EXISTING=1\n"), 0o600))
+
+	var mu sync.Mutex
+	first := awsutils.NewDotenvSink(path, "FIRST_TEMPLATE_ID", &mu)
+	second := awsutils.NewDotenvSink(path, "SECOND_TEMPLATE_ID", &mu)
+	require.NoError(t, first.Publish(context.Background(), "first", "jt-1"))
+	require.NoError(t, second.Publish(context.Background(), "second", "jt-2"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "EXISTING=1\nFIRST_TEMPLATE_ID=jt-1\nSECOND_TEMPLATE_ID=jt-2\n", string(data))
+}
+
+func TestWriterSink(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	sink := &awsutils.WriterSink{W: &out}
+
+	require.NoError(t, sink.Publish(context.Background(), "custom-job", "jt-123"))
+	assert.Equal(t, "custom-job=jt-123\n", out.String())
+}
+
+func TestSSMSink(t *testing.T) {
+	t.Parallel()
+	values := map[string]string{"/emr/a": "jt-old"}
+	transaction := awsutils.NewSSMTransaction(newFakeParameterStore(values, nil))
+	require.NoError(t, transaction.Prepare(context.Background(), ssmParameters("/emr/a", "/emr/b")))
+
+	sink := &awsutils.SSMSink{Transaction: transaction}
+	require.NoError(t, sink.Publish(context.Background(), "custom-job", "jt-123"))
+
+	assert.Equal(t, map[string]string{"/emr/a": "jt-123", "/emr/b": "jt-123"}, values)
+	assert.Equal(t, "SSM parameters /emr/a, /emr/b", sink.String())
+}
@@ -0,0 +1,130 @@
+package awsutils
+
+import (
+	"strings"
+
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// TokenizeSparkSubmitParameters splits a sparkSubmitParameters string into arguments at
+// whitespace outside of quotes. Single and double quotes and backslash escapes group characters
+// the way a shell does, but they are kept in the arguments: job template config values are
+// written into the string as is, so --conf "a=b c" gives back the value "a=b c" with its quotes.
+// An unterminated quote runs to the end of the string.
+func TokenizeSparkSubmitParameters(parameters string) []string {
+	var (
+		args  []string
+		start = -1
+		quote rune
+	)
+
+	runes := []rune(parameters)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == '\\' && quote == '"' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if start >= 0 {
+				args = append(args, string(runes[start:i]))
+				start = -1
+			}
+		default:
+			if start < 0 {
+				start = i
+			}
+			switch r {
+			case '\'', '"':
+				quote = r
+			case '\\':
+				i++
+			}
+		}
+	}
+	if start >= 0 {
+		args = append(args, string(runes[start:]))
+	}
+
+	return args
+}
+
+// unquoteSparkSubmitArgument removes the quotes and backslash escapes from an argument returned by
+// TokenizeSparkSubmitParameters, giving the value spark-submit receives. Backslashes are literal
+// inside single quotes, as in a shell.
+func unquoteSparkSubmitArgument(arg string) string {
+	var (
+		unquoted strings.Builder
+		quote    rune
+	)
+
+	runes := []rune(arg)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote = r
+		case r == quote:
+			quote = 0
+		case r == '\\' && quote != '\'' && i+1 < len(runes):
+			i++
+			unquoted.WriteRune(runes[i])
+		default:
+			unquoted.WriteRune(r)
+		}
+	}
+
+	return unquoted.String()
+}
+
+// ParseSparkSubmitCommand parses a sparkSubmitParameters string, as built by
+// HelpersBuildSparkSubmitCommand or written by hand, into Spark submit parameters. Flags are
+// accepted as "--flag value" or "--flag=value" and --conf may be repeated. Other flags with their
+// values, repeated flags and anything else that cannot be parsed are kept in ExtraArgs, in order,
+// so that building the parameters again gives an equivalent command.
+func ParseSparkSubmitCommand(parameters string) template.SparkSubmitParameters {
+	var params template.SparkSubmitParameters
+
+	args := TokenizeSparkSubmitParameters(parameters)
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		if !strings.HasPrefix(flag, "--") {
+			params.ExtraArgs = append(params.ExtraArgs, args[i])
+
+			continue
+		}
+		// A flag is followed by its value unless the next argument is another flag.
+		next := i
+		if !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			next, value, hasValue = i+1, args[i+1], true
+		}
+
+		var field *string
+		switch flag {
+		case "--master":
+			field = &params.Master
+		case "--deploy-mode":
+			field = &params.DeployMode
+		case "--class":
+			field = &params.Class
+		case "--packages":
+			field = &params.Packages
+		}
+
+		switch {
+		case flag == "--conf" && value != "":
+			params.Conf = append(params.Conf, value)
+		case field != nil && *field == "" && value != "":
+			*field = value
+		default:
+			// Unknown flags, flags without a value and flags set twice are passed through.
+			params.ExtraArgs = append(params.ExtraArgs, args[i:next+1]...)
+		}
+		i = next
+	}
+
+	return params
+}
@@ -0,0 +1,118 @@
+package awsutils_test
+
+import (
+	"testing"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenizeSparkSubmitParameters(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		parameters string
+		want       []string
+	}{
+		{
+			name:       "Plain",
+			parameters: " --master  yarn\t--conf spark.a=${A} ",
+			want:       []string{"--master", "yarn", "--conf", "spark.a=${A}"},
+		},
+		{
+			name:       "Quotes Are Kept",
+			parameters: `--conf "spark.driver.extraJavaOptions=-Dfoo=1 -Dbar=\"x y\"" --conf spark.b='c d' --conf=spark.c="e f"`,
+			want:       []string{"--conf", `"spark.driver.extraJavaOptions=-Dfoo=1 -Dbar=\"x y\""`, "--conf", "spark.b='c d'", `--conf=spark.c="e f"`},
+		},
+		{
+			name:       "Escapes",
+			parameters: `--name my\ job --path 'C:\tmp' ''`,
+			want:       []string{"--name", `my\ job`, "--path", `'C:\tmp'`, "''"},
+		},
+		{
+			name:       "Unterminated Quote",
+			parameters: `--master yarn --conf "spark.a=b c`,
+			want:       []string{"--master", "yarn", "--conf", `"spark.a=b c`},
+		},
+		{
+			name:       "Empty",
+			parameters: "   ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, awsutils.TokenizeSparkSubmitParameters(tt.parameters))
+		})
+	}
+}
+
+func TestParseSparkSubmitCommand(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		parameters string
+		want       template.SparkSubmitParameters
+	}{
+		{
+			name:       "Built Command",
+			parameters: "--master yarn --deploy-mode cluster --class org.example.Main --conf spark.a=1 --conf spark.b=${B} --packages org.example:lib:1.0",
+			want: template.SparkSubmitParameters{
+				Master: "yarn", DeployMode: "cluster", Class: "org.example.Main",
+				Conf: []string{"spark.a=1", "spark.b=${B}"}, Packages: "org.example:lib:1.0",
+			},
+		},
+		{
+			name:       "Equals Form And Quoted Conf",
+			parameters: `--class=org.example.Main --master=local[*] --conf spark.driver.extraJavaOptions='-Dfoo=1 -Dbar=2'`,
+			want: template.SparkSubmitParameters{
+				Master: "local[*]", Class: "org.example.Main",
+				Conf: []string{"spark.driver.extraJavaOptions='-Dfoo=1 -Dbar=2'"},
+			},
+		},
+		{
+			name:       "Unknown Flags",
+			parameters: "--master yarn --num-executors 4 --verbose --jars=a.jar,b.jar --deploy-mode cluster",
+			want: template.SparkSubmitParameters{
+				Master: "yarn", DeployMode: "cluster",
+				ExtraArgs: []string{"--num-executors", "4", "--verbose", "--jars=a.jar,b.jar"},
+			},
+		},
+		{
+			name:       "Unparseable Pieces",
+			parameters: "--class --master yarn --master local app.jar --conf= --conf",
+			want: template.SparkSubmitParameters{
+				Master:    "yarn",
+				ExtraArgs: []string{"--class", "--master", "local", "app.jar", "--conf=", "--conf"},
+			},
+		},
+		{
+			name:       "Empty",
+			parameters: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, awsutils.ParseSparkSubmitCommand(tt.parameters))
+		})
+	}
+}
+
+func TestParseSparkSubmitCommand_RoundTrip(t *testing.T) {
+	t.Parallel()
+	params := template.SparkSubmitParameters{
+		Master: "yarn", DeployMode: "cluster", Class: "org.example.Main",
+		Conf:      []string{`spark.driver.extraJavaOptions="-Dfoo=a -Dbar=\"b c\""`, "spark.a=${A}"},
+		Packages:  "org.example:lib:1.0",
+		ExtraArgs: []string{"--master", "local", "--name", "'my job'", "--verbose"},
+	}
+
+	built, err := awsutils.HelpersBuildSparkSubmitCommand(params)
+
+	assert.NoError(t, err)
+	assert.Equal(t, params, awsutils.ParseSparkSubmitCommand(built))
+}
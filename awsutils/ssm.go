@@ -2,8 +2,12 @@ package awsutils
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 
+	"github.com/GoGstickGo/emr-containers-template/template"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
 )
@@ -12,22 +16,185 @@ import (
 // We use this interface to test the function using a mock.
 type SSM interface {
 	PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	GetParameterHistory(ctx context.Context, params *ssm.GetParameterHistoryInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error)
+	DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error)
+}
+
+// ErrParameterValueChanged is returned when an SSM parameter no longer holds the expected value.
+var ErrParameterValueChanged = errors.New("ssm parameter value changed")
+
+// updateOptions holds the optional checks of UpdateSSMParameter.
+type updateOptions struct {
+	expectedValue *string
+	spec          template.SSMParameter
+}
+
+// UpdateOption configures UpdateSSMParameter.
+type UpdateOption func(*updateOptions)
+
+// WithExpectedValue aborts the update with ErrParameterValueChanged unless the parameter still
+// holds the given value, for example the value read when the plan was computed.
+// An empty value expects the parameter not to exist.
+func WithExpectedValue(value string) UpdateOption {
+	return func(o *updateOptions) {
+		o.expectedValue = &value
+	}
+}
+
+// WithParameterSpec sets the type, KMS key, tier, description, tags and allowed pattern of the parameter.
+// Description, tags and allowed pattern are only applied when the parameter is created.
+func WithParameterSpec(spec template.SSMParameter) UpdateOption {
+	return func(o *updateOptions) {
+		o.spec = spec
+	}
 }
 
 // UpdateSSMParameter updates an SSM parameter with the given name and value.
-func UpdateSSMParameter(ctx context.Context, client SSM, name, value string) error {
-	overwrite := true
+// The current value is read first and the write is skipped when it already matches,
+// so unchanged runs do not add parameter versions.
+func UpdateSSMParameter(ctx context.Context, client SSM, name, value string, opts ...UpdateOption) error {
+	var options updateOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	current, found, err := GetSSMParameter(ctx, client, name)
+	if err != nil {
+
+		return err
+	}
+	if options.expectedValue != nil && current != *options.expectedValue {
+
+		return fmt.Errorf("%w: '%s' holds %q, expected %q", ErrParameterValueChanged, name, current, *options.expectedValue)
+	}
+	if found && current == value {
+
+		return nil
+	}
+
+	_, err = client.PutParameter(ctx, putParameterInput(name, value, options.spec, found))
+	if err != nil {
+
+		return fmt.Errorf("ssm update failed err: %w", err)
+	}
+
+	return nil
+}
+
+// putParameterInput builds the PutParameter request for a new or an existing parameter.
+// Tags cannot be combined with Overwrite, and description and allowed pattern are left as they
+// are on existing parameters; type, KMS key and tier are sent when set so the security settings stay
+// enforced. Without a type an existing parameter keeps its own, so a SecureString is not downgraded
+// to a String, and only new parameters default to String.
+func putParameterInput(name, value string, spec template.SSMParameter, exists bool) *ssm.PutParameterInput {
 	input := &ssm.PutParameterInput{
 		Name:      &name,
 		Value:     &value,
-		Type:      types.ParameterTypeString,
-		Overwrite: &overwrite,
+		Overwrite: aws.Bool(exists),
+	}
+	switch {
+	case spec.Type != "":
+		input.Type = types.ParameterType(spec.Type)
+	case !exists:
+		input.Type = types.ParameterTypeString
+	}
+	if spec.KeyID != "" {
+		input.KeyId = aws.String(spec.KeyID)
+	}
+	if spec.Tier != "" {
+		input.Tier = types.ParameterTier(spec.Tier)
 	}
+	if exists {
 
-	_, err := client.PutParameter(ctx, input)
+		return input
+	}
+
+	if spec.Description != "" {
+		input.Description = aws.String(spec.Description)
+	}
+	if spec.AllowedPattern != "" {
+		input.AllowedPattern = aws.String(spec.AllowedPattern)
+	}
+	for _, key := range sortedKeys(spec.Tags) {
+		input.Tags = append(input.Tags, types.Tag{Key: aws.String(key), Value: aws.String(spec.Tags[key])})
+	}
+
+	return input
+}
+
+// sortedKeys returns the keys of a map in lexical order so requests and messages are deterministic.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// GetSSMParameter reads the current value of an SSM parameter.
+// found is false when the parameter does not exist yet.
+func GetSSMParameter(ctx context.Context, client SSM, name string) (value string, found bool, err error) {
+	// SecureString values are decrypted so they can be compared with plain job template IDs.
+	resp, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           &name,
+		WithDecryption: aws.Bool(true),
+	})
 	if err != nil {
+		var notFound *types.ParameterNotFound
+		if errors.As(err, &notFound) {
 
-		return fmt.Errorf("ssm update failed err: %w", err)
+			return "", false, nil
+		}
+
+		return "", false, fmt.Errorf("ssm get failed err: %w", err)
+	}
+
+	if resp.Parameter == nil {
+
+		return "", false, nil
+	}
+
+	return aws.ToString(resp.Parameter.Value), true, nil
+}
+
+// GetSSMParameterHistory returns the values an SSM parameter has held, newest first.
+func GetSSMParameterHistory(ctx context.Context, client SSM, name string) ([]string, error) {
+	var history []string
+
+	paginator := ssm.NewGetParameterHistoryPaginator(client, &ssm.GetParameterHistoryInput{
+		Name:           &name,
+		WithDecryption: aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+
+			return nil, fmt.Errorf("ssm get history failed err: %w", err)
+		}
+		for _, version := range page.Parameters {
+			history = append(history, aws.ToString(version.Value))
+		}
+	}
+
+	// The API returns the oldest version first.
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+
+	return history, nil
+}
+
+// DeleteSSMParameter deletes an SSM parameter.
+func DeleteSSMParameter(ctx context.Context, client SSM, name string) error {
+	_, err := client.DeleteParameter(ctx, &ssm.DeleteParameterInput{
+		Name: &name,
+	})
+	if err != nil {
+
+		return fmt.Errorf("ssm delete failed err: %w", err)
 	}
 
 	return nil
@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,13 +25,28 @@ func (m *MockSSMAWSConfigLoader) LoadConfig(ctx context.Context, region string)
 
 // MockSSMClient is a mock implementation of SSMClient.
 type MockSSMClient struct {
-	PutParameterFunc func(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+	PutParameterFunc        func(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+	GetParameterFunc        func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	GetParameterHistoryFunc func(ctx context.Context, params *ssm.GetParameterHistoryInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error)
+	DeleteParameterFunc     func(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error)
 }
 
 func (m *MockSSMClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
 	return m.PutParameterFunc(ctx, params, optFns...)
 }
 
+func (m *MockSSMClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	return m.GetParameterFunc(ctx, params, optFns...)
+}
+
+func (m *MockSSMClient) GetParameterHistory(ctx context.Context, params *ssm.GetParameterHistoryInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error) {
+	return m.GetParameterHistoryFunc(ctx, params, optFns...)
+}
+
+func (m *MockSSMClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	return m.DeleteParameterFunc(ctx, params, optFns...)
+}
+
 func TestUpdateSSMParameter_Success(t *testing.T) {
 	t.Parallel()
 	// Mock the AWS configuration loader.
@@ -52,6 +69,9 @@ func TestUpdateSSMParameter_Success(t *testing.T) {
 	}
 
 	mockClient := &MockSSMClient{
+		GetParameterFunc: func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+			return nil, &types.ParameterNotFound{}
+		},
 		PutParameterFunc: func(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
 			// Simulate success.
 			return &ssm.PutParameterOutput{}, nil
@@ -92,6 +112,9 @@ func TestUpdateSSMParameter_Failure(t *testing.T) {
 	}
 
 	mockClient := &MockSSMClient{
+		GetParameterFunc: func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+			return &ssm.GetParameterOutput{Parameter: &types.Parameter{Value: aws.String("old-value")}}, nil
+		},
 		PutParameterFunc: func(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
 			// Simulate failure.
 			return nil, fmt.Errorf("failed success")
@@ -109,3 +132,222 @@ func TestUpdateSSMParameter_Failure(t *testing.T) {
 	// Assert.
 	assert.ErrorContainsf(t, err, "ssm update failed err", err.Error())
 }
+
+func TestUpdateSSMParameter_CompareBeforeWrite(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		current  *string
+		opts     []awsutils.UpdateOption
+		wantPut  bool
+		wantErr  error
+		getError error
+	}{
+		{name: "same value is not written", current: aws.String("jt-123")},
+		{name: "different value is written", current: aws.String("jt-old"), wantPut: true},
+		{name: "missing parameter is written", wantPut: true},
+		{name: "expected value matches", current: aws.String("jt-old"), opts: []awsutils.UpdateOption{awsutils.WithExpectedValue("jt-old")}, wantPut: true},
+		{name: "expected value changed", current: aws.String("jt-other"), opts: []awsutils.UpdateOption{awsutils.WithExpectedValue("jt-old")}, wantErr: awsutils.ErrParameterValueChanged},
+		{name: "expected missing parameter exists", current: aws.String("jt-other"), opts: []awsutils.UpdateOption{awsutils.WithExpectedValue("")}, wantErr: awsutils.ErrParameterValueChanged},
+		{name: "expected missing parameter", opts: []awsutils.UpdateOption{awsutils.WithExpectedValue("")}, wantPut: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			put := false
+			mockClient := &MockSSMClient{
+				GetParameterFunc: func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+					if tt.current == nil {
+						return nil, &types.ParameterNotFound{}
+					}
+
+					return &ssm.GetParameterOutput{Parameter: &types.Parameter{Value: tt.current}}, nil
+				},
+				PutParameterFunc: func(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+					put = true
+
+					return &ssm.PutParameterOutput{}, nil
+				},
+			}
+
+			err := awsutils.UpdateSSMParameter(context.Background(), mockClient, "/emr/job", "jt-123", tt.opts...)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantPut, put)
+		})
+	}
+}
+
+func TestUpdateSSMParameter_ParameterSpec(t *testing.T) {
+	t.Parallel()
+	spec := template.SSMParameter{
+		Name:           "/emr/job",
+		Type:           "SecureString",
+		KeyID:          "alias/emr",
+		Tier:           "Advanced",
+		Description:    "Current job template ID",
+		AllowedPattern: "^[a-z0-9]+$",
+		Tags:           map[string]string{"Team": "x", "Owner": "y"},
+	}
+	tests := []struct {
+		name    string
+		spec    template.SSMParameter
+		current *string
+		want    *ssm.PutParameterInput
+	}{
+		{
+			name: "create applies the full spec",
+			spec: spec,
+			want: &ssm.PutParameterInput{
+				Name:           aws.String("/emr/job"),
+				Value:          aws.String("jt-123"),
+				Type:           types.ParameterTypeSecureString,
+				KeyId:          aws.String("alias/emr"),
+				Tier:           types.ParameterTierAdvanced,
+				Overwrite:      aws.Bool(false),
+				Description:    aws.String("Current job template ID"),
+				AllowedPattern: aws.String("^[a-z0-9]+$"),
+				Tags: []types.Tag{
+					{Key: aws.String("Owner"), Value: aws.String("y")},
+					{Key: aws.String("Team"), Value: aws.String("x")},
+				},
+			},
+		},
+		{
+			name:    "overwrite keeps existing metadata",
+			spec:    spec,
+			current: aws.String("jt-old"),
+			want: &ssm.PutParameterInput{
+				Name:      aws.String("/emr/job"),
+				Value:     aws.String("jt-123"),
+				Type:      types.ParameterTypeSecureString,
+				KeyId:     aws.String("alias/emr"),
+				Tier:      types.ParameterTierAdvanced,
+				Overwrite: aws.Bool(true),
+			},
+		},
+		{
+			name: "create without a type defaults to String",
+			spec: template.SSMParameter{Name: "/emr/job"},
+			want: &ssm.PutParameterInput{
+				Name:      aws.String("/emr/job"),
+				Value:     aws.String("jt-123"),
+				Type:      types.ParameterTypeString,
+				Overwrite: aws.Bool(false),
+			},
+		},
+		{
+			name:    "overwrite without a type keeps the existing type",
+			spec:    template.SSMParameter{Name: "/emr/job"},
+			current: aws.String("jt-old"),
+			want: &ssm.PutParameterInput{
+				Name:      aws.String("/emr/job"),
+				Value:     aws.String("jt-123"),
+				Overwrite: aws.Bool(true),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got *ssm.PutParameterInput
+			mockClient := &MockSSMClient{
+				GetParameterFunc: func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+					assert.True(t, aws.ToBool(params.WithDecryption))
+					if tt.current == nil {
+						return nil, &types.ParameterNotFound{}
+					}
+
+					return &ssm.GetParameterOutput{Parameter: &types.Parameter{Value: tt.current}}, nil
+				},
+				PutParameterFunc: func(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+					got = params
+
+					return &ssm.PutParameterOutput{}, nil
+				},
+			}
+
+			err := awsutils.UpdateSSMParameter(context.Background(), mockClient, "/emr/job", "jt-123", awsutils.WithParameterSpec(tt.spec))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetSSMParameter(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		output    *ssm.GetParameterOutput
+		err       error
+		wantValue string
+		wantFound bool
+		wantErr   bool
+	}{
+		{
+			name:      "Existing Parameter",
+			output:    &ssm.GetParameterOutput{Parameter: &types.Parameter{Value: aws.String("template-id")}},
+			wantValue: "template-id",
+			wantFound: true,
+		},
+		{
+			name:      "Missing Parameter",
+			err:       &types.ParameterNotFound{},
+			wantFound: false,
+		},
+		{
+			name:    "API Failure",
+			err:     fmt.Errorf("failed success"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockClient := &MockSSMClient{
+				GetParameterFunc: func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+					return tt.output, tt.err
+				},
+			}
+
+			value, found, err := awsutils.GetSSMParameter(context.Background(), mockClient, "test-parameter")
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "ssm get failed err")
+
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantValue, value)
+			assert.Equal(t, tt.wantFound, found)
+		})
+	}
+}
+
+func TestGetSSMParameterHistory(t *testing.T) {
+	t.Parallel()
+	mockClient := &MockSSMClient{
+		GetParameterHistoryFunc: func(ctx context.Context, params *ssm.GetParameterHistoryInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error) {
+			if params.NextToken == nil {
+				return &ssm.GetParameterHistoryOutput{
+					Parameters: []types.ParameterHistory{{Value: aws.String("v1")}, {Value: aws.String("v2")}},
+					NextToken:  aws.String("page-2"),
+				}, nil
+			}
+
+			return &ssm.GetParameterHistoryOutput{
+				Parameters: []types.ParameterHistory{{Value: aws.String("v3")}},
+			}, nil
+		},
+	}
+
+	history, err := awsutils.GetSSMParameterHistory(context.Background(), mockClient, "test-parameter")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v3", "v2", "v1"}, history)
+}
@@ -0,0 +1,116 @@
+package awsutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// ssmPreviousValue is the state of an SSM parameter before a transaction wrote it.
+type ssmPreviousValue struct {
+	Name    string
+	Value   string
+	Existed bool
+	Spec    template.SSMParameter
+}
+
+// SSMTransaction points several SSM parameters at the same value with all-or-nothing semantics.
+// Prepare records the current values, Commit writes the new value and Rollback restores
+// every parameter Commit has written.
+type SSMTransaction struct {
+	client   SSM
+	previous []ssmPreviousValue
+	written  int
+	value    string
+}
+
+// NewSSMTransaction returns a transaction writing through the given client.
+func NewSSMTransaction(client SSM) *SSMTransaction {
+	return &SSMTransaction{client: client}
+}
+
+// Prepare records the current value of every parameter before anything is written.
+func (t *SSMTransaction) Prepare(ctx context.Context, params []template.SSMParameter) error {
+	previous := make([]ssmPreviousValue, 0, len(params))
+	for _, param := range params {
+		value, found, err := GetSSMParameter(ctx, t.client, param.Name)
+		if err != nil {
+
+			return fmt.Errorf("failed to read SSM parameter '%s': %w", param.Name, err)
+		}
+		previous = append(previous, ssmPreviousValue{Name: param.Name, Value: value, Existed: found, Spec: param})
+	}
+	t.previous = previous
+	t.written = 0
+
+	return nil
+}
+
+// PreviousValues returns the recorded value of every existing parameter.
+func (t *SSMTransaction) PreviousValues() map[string]string {
+	values := make(map[string]string, len(t.previous))
+	for _, previous := range t.previous {
+		if previous.Existed {
+			values[previous.Name] = previous.Value
+		}
+	}
+
+	return values
+}
+
+// Commit writes the value to every prepared parameter in order and stops at the first failure.
+// A parameter changed by someone else since Prepare fails with ErrParameterValueChanged.
+func (t *SSMTransaction) Commit(ctx context.Context, value string) error {
+	t.value = value
+	for _, previous := range t.previous[t.written:] {
+		if err := UpdateSSMParameter(ctx, t.client, previous.Name, value, WithExpectedValue(previous.Value), WithParameterSpec(previous.Spec)); err != nil {
+
+			return fmt.Errorf("failed to update SSM parameter '%s': %w", previous.Name, err)
+		}
+		t.written++
+	}
+
+	return nil
+}
+
+// Rollback restores the parameters written by Commit, newest first. Parameters that did not
+// exist before are deleted, parameters changed by someone else since Commit are left alone.
+// It returns a description of every restored parameter and the joined errors of the
+// parameters that could not be restored.
+func (t *SSMTransaction) Rollback(ctx context.Context) ([]string, error) {
+	var restored []string
+	var errs []error
+
+	for i := t.written - 1; i >= 0; i-- {
+		previous := t.previous[i]
+		if !previous.Existed {
+			current, _, err := GetSSMParameter(ctx, t.client, previous.Name)
+			if err == nil && current != t.value {
+				err = fmt.Errorf("%w: '%s' holds %q, expected %q", ErrParameterValueChanged, previous.Name, current, t.value)
+			}
+			if err == nil {
+				err = DeleteSSMParameter(ctx, t.client, previous.Name)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete SSM parameter '%s': %w", previous.Name, err))
+
+				continue
+			}
+			restored = append(restored, fmt.Sprintf("deleted SSM parameter '%s'", previous.Name))
+
+			continue
+		}
+
+		if err := UpdateSSMParameter(ctx, t.client, previous.Name, previous.Value, WithExpectedValue(t.value), WithParameterSpec(previous.Spec)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore SSM parameter '%s' to %s: %w", previous.Name, previous.Value, err))
+
+			continue
+		}
+		restored = append(restored, fmt.Sprintf("restored SSM parameter '%s' to %s", previous.Name, previous.Value))
+	}
+	t.written = 0
+
+	return restored, errors.Join(errs...)
+}
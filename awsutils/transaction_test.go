@@ -0,0 +1,139 @@
+package awsutils_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeParameterStore returns an SSM mock backed by a map, failing PutParameter for the named parameters.
+func newFakeParameterStore(values map[string]string, failPut map[string]error) *MockSSMClient {
+	return &MockSSMClient{
+		GetParameterFunc: func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+			value, ok := values[aws.ToString(params.Name)]
+			if !ok {
+				return nil, &types.ParameterNotFound{}
+			}
+
+			return &ssm.GetParameterOutput{Parameter: &types.Parameter{Name: params.Name, Value: aws.String(value)}}, nil
+		},
+		PutParameterFunc: func(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+			if err := failPut[aws.ToString(params.Name)]; err != nil {
+				return nil, err
+			}
+			values[aws.ToString(params.Name)] = aws.ToString(params.Value)
+
+			return &ssm.PutParameterOutput{}, nil
+		},
+		DeleteParameterFunc: func(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+			delete(values, aws.ToString(params.Name))
+
+			return &ssm.DeleteParameterOutput{}, nil
+		},
+	}
+}
+
+// ssmParameters returns plain SSM parameter specs for the given names.
+func ssmParameters(names ...string) []template.SSMParameter {
+	params := make([]template.SSMParameter, 0, len(names))
+	for _, name := range names {
+		params = append(params, template.SSMParameter{Name: name})
+	}
+
+	return params
+}
+
+func TestSSMTransaction_Commit(t *testing.T) {
+	t.Parallel()
+	values := map[string]string{"/emr/a": "jt-old"}
+	transaction := awsutils.NewSSMTransaction(newFakeParameterStore(values, nil))
+
+	require.NoError(t, transaction.Prepare(context.Background(), ssmParameters("/emr/a", "/emr/b")))
+	assert.Equal(t, map[string]string{"/emr/a": "jt-old"}, transaction.PreviousValues())
+
+	require.NoError(t, transaction.Commit(context.Background(), "jt-new"))
+	assert.Equal(t, map[string]string{"/emr/a": "jt-new", "/emr/b": "jt-new"}, values)
+}
+
+func TestSSMTransaction_RollbackAfterPartialCommit(t *testing.T) {
+	t.Parallel()
+	values := map[string]string{"/emr/a": "jt-old", "/emr/c": "jt-other"}
+	transaction := awsutils.NewSSMTransaction(newFakeParameterStore(values, map[string]error{"/emr/c": errors.New("access denied")}))
+
+	require.NoError(t, transaction.Prepare(context.Background(), ssmParameters("/emr/a", "/emr/b", "/emr/c")))
+
+	err := transaction.Commit(context.Background(), "jt-new")
+	require.ErrorContains(t, err, "failed to update SSM parameter '/emr/c'")
+	assert.Equal(t, map[string]string{"/emr/a": "jt-new", "/emr/b": "jt-new", "/emr/c": "jt-other"}, values)
+
+	rolledBack, err := transaction.Rollback(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"deleted SSM parameter '/emr/b'",
+		"restored SSM parameter '/emr/a' to jt-old",
+	}, rolledBack)
+	assert.Equal(t, map[string]string{"/emr/a": "jt-old", "/emr/c": "jt-other"}, values)
+
+	// A second rollback has nothing left to undo.
+	rolledBack, err = transaction.Rollback(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, rolledBack)
+}
+
+func TestSSMTransaction_RollbackFailure(t *testing.T) {
+	t.Parallel()
+	values := map[string]string{"/emr/a": "jt-old"}
+	client := newFakeParameterStore(values, nil)
+	transaction := awsutils.NewSSMTransaction(client)
+
+	require.NoError(t, transaction.Prepare(context.Background(), ssmParameters("/emr/a")))
+	require.NoError(t, transaction.Commit(context.Background(), "jt-new"))
+
+	client.PutParameterFunc = func(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+		return nil, errors.New("throttled")
+	}
+	rolledBack, err := transaction.Rollback(context.Background())
+	require.ErrorContains(t, err, "failed to restore SSM parameter '/emr/a' to jt-old")
+	assert.Empty(t, rolledBack)
+}
+
+func TestSSMTransaction_PrepareError(t *testing.T) {
+	t.Parallel()
+	transaction := awsutils.NewSSMTransaction(&MockSSMClient{
+		GetParameterFunc: func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+			return nil, errors.New("access denied")
+		},
+	})
+
+	err := transaction.Prepare(context.Background(), ssmParameters("/emr/a"))
+	require.ErrorContains(t, err, "failed to read SSM parameter '/emr/a'")
+}
+
+func TestSSMTransaction_ConcurrentChange(t *testing.T) {
+	t.Parallel()
+	values := map[string]string{"/emr/a": "jt-old", "/emr/b": "jt-old"}
+	transaction := awsutils.NewSSMTransaction(newFakeParameterStore(values, nil))
+
+	require.NoError(t, transaction.Prepare(context.Background(), ssmParameters("/emr/a", "/emr/b")))
+
+	// Someone else repoints a parameter between the prepare and the commit.
+	values["/emr/b"] = "jt-someone-else"
+	err := transaction.Commit(context.Background(), "jt-new")
+	require.ErrorIs(t, err, awsutils.ErrParameterValueChanged)
+	assert.Equal(t, "jt-someone-else", values["/emr/b"])
+
+	// The rollback leaves a parameter alone once someone else changed it again.
+	values["/emr/a"] = "jt-hotfix"
+	rolledBack, err := transaction.Rollback(context.Background())
+	require.ErrorIs(t, err, awsutils.ErrParameterValueChanged)
+	assert.Empty(t, rolledBack)
+	assert.Equal(t, "jt-hotfix", values["/emr/a"])
+}
@@ -0,0 +1,115 @@
+package awsutils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/sirupsen/logrus"
+)
+
+// JobRunFailedError is returned when a job run ends in the FAILED or CANCELLED state.
+type JobRunFailedError struct {
+	JobRunID      string
+	State         types.JobRunState
+	FailureReason types.FailureReason
+	StateDetails  string
+}
+
+func (e *JobRunFailedError) Error() string {
+	message := fmt.Sprintf("job run %s %s", e.JobRunID, e.State)
+	if e.FailureReason != "" {
+		message += fmt.Sprintf(" (%s)", e.FailureReason)
+	}
+	if e.StateDetails != "" {
+		message += ": " + e.StateDetails
+	}
+
+	return message
+}
+
+// JobRunWaiter polls DescribeJobRun until a job run reaches a terminal state.
+// The delay between polls starts at MinDelay and grows by half up to MaxDelay.
+type JobRunWaiter struct {
+	Client   EMRC
+	Logger   logrus.FieldLogger
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+// NewJobRunWaiter returns a waiter polling every 5 seconds at first and at most every 30 seconds.
+func NewJobRunWaiter(client EMRC, logger logrus.FieldLogger) *JobRunWaiter {
+	return &JobRunWaiter{Client: client, Logger: logger, MinDelay: 5 * time.Second, MaxDelay: 30 * time.Second}
+}
+
+// Wait blocks until the job run is COMPLETED, FAILED or CANCELLED, logging every state change.
+// A failed or cancelled run returns a *JobRunFailedError; the context bounds the overall wait.
+func (w *JobRunWaiter) Wait(ctx context.Context, virtualClusterID, jobRunID string) (*types.JobRun, error) {
+	var lastState types.JobRunState
+	delay := w.MinDelay
+
+	for {
+		resp, err := w.Client.DescribeJobRun(ctx, &emrcontainers.DescribeJobRunInput{
+			Id:               aws.String(jobRunID),
+			VirtualClusterId: aws.String(virtualClusterID),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe job run %s: %w", jobRunID, err)
+		}
+
+		jobRun := resp.JobRun
+		if jobRun == nil {
+			return nil, fmt.Errorf("job run %s not found", jobRunID)
+		}
+
+		if jobRun.State != lastState {
+			entry := w.Logger.WithField("jobRun", jobRunID).WithField("state", jobRun.State)
+			if details := aws.ToString(jobRun.StateDetails); details != "" {
+				entry = entry.WithField("details", details)
+			}
+			entry.Infof("Job run %s is %s", jobRunID, jobRun.State)
+			lastState = jobRun.State
+		}
+
+		switch jobRun.State {
+		case types.JobRunStateCompleted:
+			return jobRun, nil
+		case types.JobRunStateFailed, types.JobRunStateCancelled:
+			return jobRun, &JobRunFailedError{
+				JobRunID:      jobRunID,
+				State:         jobRun.State,
+				FailureReason: jobRun.FailureReason,
+				StateDetails:  aws.ToString(jobRun.StateDetails),
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return jobRun, fmt.Errorf("stopped waiting for job run %s in state %s: %w", jobRunID, jobRun.State, ctx.Err())
+		case <-timer.C:
+		}
+
+		if delay = delay + delay/2; delay > w.MaxDelay {
+			delay = w.MaxDelay
+		}
+	}
+}
+
+// CancelJobRun cancels a running job run.
+func CancelJobRun(ctx context.Context, client EMRC, virtualClusterID, jobRunID string) error {
+	_, err := client.CancelJobRun(ctx, &emrcontainers.CancelJobRunInput{
+		Id:               aws.String(jobRunID),
+		VirtualClusterId: aws.String(virtualClusterID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel job run %s: %w", jobRunID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package awsutils_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jobRunStates returns a DescribeJobRun mock reporting the given states in turn, repeating the last one.
+func jobRunStates(calls *int, states ...types.JobRun) func(ctx context.Context, params *emrcontainers.DescribeJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobRunOutput, error) {
+	return func(ctx context.Context, params *emrcontainers.DescribeJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobRunOutput, error) {
+		jobRun := states[min(*calls, len(states)-1)]
+		*calls++
+
+		return &emrcontainers.DescribeJobRunOutput{JobRun: &jobRun}, nil
+	}
+}
+
+func TestJobRunWaiter_Wait(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		states     []types.JobRun
+		wantCalls  int
+		wantErr    string
+		wantStates []string
+	}{
+		{
+			name: "completed",
+			states: []types.JobRun{
+				{State: types.JobRunStatePending},
+				{State: types.JobRunStatePending},
+				{State: types.JobRunStateRunning},
+				{State: types.JobRunStateCompleted},
+			},
+			wantCalls:  4,
+			wantStates: []string{"PENDING", "RUNNING", "COMPLETED"},
+		},
+		{
+			name: "failed",
+			states: []types.JobRun{
+				{State: types.JobRunStateRunning},
+				{State: types.JobRunStateFailed, FailureReason: types.FailureReasonUserError, StateDetails: aws.String("exit code 1")},
+			},
+			wantCalls:  2,
+			wantErr:    "job run run-1 FAILED (USER_ERROR): exit code 1",
+			wantStates: []string{"RUNNING", "FAILED"},
+		},
+		{
+			name:       "cancelled",
+			states:     []types.JobRun{{State: types.JobRunStateCancelled}},
+			wantCalls:  1,
+			wantErr:    "job run run-1 CANCELLED",
+			wantStates: []string{"CANCELLED"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			calls := 0
+			mockClient := &MockEMRCclient{DescribeJobRunFunc: jobRunStates(&calls, tt.states...)}
+			logger, hook := test.NewNullLogger()
+			waiter := &awsutils.JobRunWaiter{Client: mockClient, Logger: logger, MinDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+			jobRun, err := waiter.Wait(context.Background(), "vc-1", "run-1")
+			assert.Equal(t, tt.wantCalls, calls)
+			require.NotNil(t, jobRun)
+
+			var states []string
+			for _, entry := range hook.AllEntries() {
+				states = append(states, string(entry.Data["state"].(types.JobRunState)))
+			}
+			assert.Equal(t, tt.wantStates, states)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				var failed *awsutils.JobRunFailedError
+				require.ErrorAs(t, err, &failed)
+				assert.Equal(t, tt.states[len(tt.states)-1].State, failed.State)
+
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestJobRunWaiter_Wait_Timeout(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	mockClient := &MockEMRCclient{DescribeJobRunFunc: jobRunStates(&calls, types.JobRun{State: types.JobRunStateRunning})}
+	waiter := &awsutils.JobRunWaiter{Client: mockClient, Logger: logrus.New(), MinDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := waiter.Wait(ctx, "vc-1", "run-1")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "stopped waiting for job run run-1 in state RUNNING")
+	assert.Greater(t, calls, 1)
+}
+
+func TestJobRunWaiter_Wait_DescribeError(t *testing.T) {
+	t.Parallel()
+	mockClient := &MockEMRCclient{
+		DescribeJobRunFunc: func(ctx context.Context, params *emrcontainers.DescribeJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobRunOutput, error) {
+			return nil, errors.New("access denied")
+		},
+	}
+	waiter := awsutils.NewJobRunWaiter(mockClient, logrus.New())
+
+	_, err := waiter.Wait(context.Background(), "vc-1", "run-1")
+	require.EqualError(t, err, "failed to describe job run run-1: access denied")
+}
+
+func TestCancelJobRun(t *testing.T) {
+	t.Parallel()
+	var got *emrcontainers.CancelJobRunInput
+	mockClient := &MockEMRCclient{
+		CancelJobRunFunc: func(ctx context.Context, params *emrcontainers.CancelJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CancelJobRunOutput, error) {
+			got = params
+
+			return &emrcontainers.CancelJobRunOutput{}, nil
+		},
+	}
+
+	require.NoError(t, awsutils.CancelJobRun(context.Background(), mockClient, "vc-1", "run-1"))
+	assert.Equal(t, "run-1", aws.ToString(got.Id))
+	assert.Equal(t, "vc-1", aws.ToString(got.VirtualClusterId))
+
+	mockClient.CancelJobRunFunc = func(ctx context.Context, params *emrcontainers.CancelJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CancelJobRunOutput, error) {
+		return nil, errors.New("not found")
+	}
+	require.EqualError(t, awsutils.CancelJobRun(context.Background(), mockClient, "vc-1", "run-1"), "failed to cancel job run run-1: not found")
+}
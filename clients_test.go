@@ -0,0 +1,403 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	emrtypes "github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// MockEMRCclient is a mock implementation of awsutils.EMRC.
+type MockEMRCclient struct {
+	DescribeJobTemplateFunc func(ctx context.Context, params *emrcontainers.DescribeJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobTemplateOutput, error)
+	CreateJobTemplateFunc   func(ctx context.Context, params *emrcontainers.CreateJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CreateJobTemplateOutput, error)
+	ListJobTemplatesFunc    func(ctx context.Context, params *emrcontainers.ListJobTemplatesInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.ListJobTemplatesOutput, error)
+	DeleteJobTemplateFunc   func(ctx context.Context, params *emrcontainers.DeleteJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DeleteJobTemplateOutput, error)
+	StartJobRunFunc         func(ctx context.Context, params *emrcontainers.StartJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.StartJobRunOutput, error)
+	DescribeJobRunFunc      func(ctx context.Context, params *emrcontainers.DescribeJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobRunOutput, error)
+	CancelJobRunFunc        func(ctx context.Context, params *emrcontainers.CancelJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CancelJobRunOutput, error)
+	TagResourceFunc         func(ctx context.Context, params *emrcontainers.TagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.TagResourceOutput, error)
+	UntagResourceFunc       func(ctx context.Context, params *emrcontainers.UntagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.UntagResourceOutput, error)
+}
+
+func (m *MockEMRCclient) DescribeJobTemplate(ctx context.Context, params *emrcontainers.DescribeJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobTemplateOutput, error) {
+	return m.DescribeJobTemplateFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) CreateJobTemplate(ctx context.Context, params *emrcontainers.CreateJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CreateJobTemplateOutput, error) {
+	return m.CreateJobTemplateFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) ListJobTemplates(ctx context.Context, params *emrcontainers.ListJobTemplatesInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.ListJobTemplatesOutput, error) {
+	return m.ListJobTemplatesFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) DeleteJobTemplate(ctx context.Context, params *emrcontainers.DeleteJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DeleteJobTemplateOutput, error) {
+	return m.DeleteJobTemplateFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) StartJobRun(ctx context.Context, params *emrcontainers.StartJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.StartJobRunOutput, error) {
+	return m.StartJobRunFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) DescribeJobRun(ctx context.Context, params *emrcontainers.DescribeJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobRunOutput, error) {
+	return m.DescribeJobRunFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) CancelJobRun(ctx context.Context, params *emrcontainers.CancelJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CancelJobRunOutput, error) {
+	return m.CancelJobRunFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) TagResource(ctx context.Context, params *emrcontainers.TagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.TagResourceOutput, error) {
+	return m.TagResourceFunc(ctx, params, optFns...)
+}
+
+func (m *MockEMRCclient) UntagResource(ctx context.Context, params *emrcontainers.UntagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.UntagResourceOutput, error) {
+	return m.UntagResourceFunc(ctx, params, optFns...)
+}
+
+// MockSSMClient is a mock implementation of awsutils.SSM.
+type MockSSMClient struct {
+	PutParameterFunc        func(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+	GetParameterFunc        func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	GetParameterHistoryFunc func(ctx context.Context, params *ssm.GetParameterHistoryInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error)
+	DeleteParameterFunc     func(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error)
+}
+
+func (m *MockSSMClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	return m.PutParameterFunc(ctx, params, optFns...)
+}
+
+func (m *MockSSMClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	return m.GetParameterFunc(ctx, params, optFns...)
+}
+
+func (m *MockSSMClient) GetParameterHistory(ctx context.Context, params *ssm.GetParameterHistoryInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error) {
+	return m.GetParameterHistoryFunc(ctx, params, optFns...)
+}
+
+func (m *MockSSMClient) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	return m.DeleteParameterFunc(ctx, params, optFns...)
+}
+
+// fakeAWS keeps job templates, job runs and SSM parameters in memory behind the mocks.
+// Fail* entries make the calls fail, per job template ID or parameter name where they are maps.
+type fakeAWS struct {
+	mu sync.Mutex
+
+	// Templates is every job template, oldest first.
+	Templates []emrtypes.JobTemplate
+	// Values holds the current SSM parameter values and History every value written, oldest first.
+	Values  map[string]string
+	History map[string][]string
+	// JobRunStates are returned by successive DescribeJobRun calls, the last one repeats.
+	JobRunStates []emrtypes.JobRunState
+
+	FailCreate   error
+	FailDescribe map[string]error
+	FailDelete   map[string]error
+	FailPut      map[string]error
+	FailStart    error
+
+	tokens    map[string]string
+	created   int
+	Deleted   []string
+	Started   []*emrcontainers.StartJobRunInput
+	Cancelled []string
+}
+
+// newFakeAWS returns an empty account with the given SSM parameter values.
+func newFakeAWS(values map[string]string) *fakeAWS {
+	if values == nil {
+		values = make(map[string]string)
+	}
+	history := make(map[string][]string, len(values))
+	for name, value := range values {
+		history[name] = []string{value}
+	}
+
+	return &fakeAWS{Values: values, History: history, tokens: make(map[string]string)}
+}
+
+// clients returns the AWS clients backed by the fake.
+func (f *fakeAWS) clients() *awsutils.AWSClients {
+	return &awsutils.AWSClients{EMRContainers: f.emrContainers(), SSM: f.ssm()}
+}
+
+// addTemplate stores a job template with the given ID, Name tag and tags, created a minute after the previous one.
+func (f *fakeAWS) addTemplate(id, name string, data *emrtypes.JobTemplateData, tags map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	allTags := map[string]string{"Name": name}
+	for key, value := range tags {
+		allTags[key] = value
+	}
+	f.Templates = append(f.Templates, emrtypes.JobTemplate{
+		Id:              aws.String(id),
+		Arn:             aws.String("arn:aws:emr-containers:eu-west-1:123456789012:/jobtemplates/" + id),
+		Name:            aws.String(name),
+		CreatedAt:       aws.Time(time.Date(2024, 1, 1, 0, len(f.Templates), 0, 0, time.UTC)),
+		Tags:            allTags,
+		JobTemplateData: data,
+	})
+}
+
+// template returns the stored job template with the given ID, or nil.
+func (f *fakeAWS) template(id string) *emrtypes.JobTemplate {
+	for i := range f.Templates {
+		if aws.ToString(f.Templates[i].Id) == id {
+			return &f.Templates[i]
+		}
+	}
+
+	return nil
+}
+
+// removeTemplate drops the stored job template with the given ID, leaving the SSM parameters alone.
+func (f *fakeAWS) removeTemplate(id string) {
+	for i := range f.Templates {
+		if aws.ToString(f.Templates[i].Id) == id {
+			f.Templates = append(f.Templates[:i], f.Templates[i+1:]...)
+
+			return
+		}
+	}
+}
+
+// templateIDs returns the IDs of the stored job templates, oldest first.
+func (f *fakeAWS) templateIDs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := make([]string, 0, len(f.Templates))
+	for _, jobTemplate := range f.Templates {
+		ids = append(ids, aws.ToString(jobTemplate.Id))
+	}
+
+	return ids
+}
+
+func (f *fakeAWS) emrContainers() *MockEMRCclient {
+	return &MockEMRCclient{
+		CreateJobTemplateFunc:   f.createJobTemplate,
+		DescribeJobTemplateFunc: f.describeJobTemplate,
+		ListJobTemplatesFunc: func(ctx context.Context, params *emrcontainers.ListJobTemplatesInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.ListJobTemplatesOutput, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+
+			return &emrcontainers.ListJobTemplatesOutput{Templates: append([]emrtypes.JobTemplate(nil), f.Templates...)}, nil
+		},
+		DeleteJobTemplateFunc: f.deleteJobTemplate,
+		StartJobRunFunc:       f.startJobRun,
+		DescribeJobRunFunc:    f.describeJobRun,
+		CancelJobRunFunc: func(ctx context.Context, params *emrcontainers.CancelJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CancelJobRunOutput, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.Cancelled = append(f.Cancelled, aws.ToString(params.Id))
+
+			return &emrcontainers.CancelJobRunOutput{Id: params.Id}, nil
+		},
+		TagResourceFunc: func(ctx context.Context, params *emrcontainers.TagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.TagResourceOutput, error) {
+			f.updateTags(aws.ToString(params.ResourceArn), func(tags map[string]string) {
+				for key, value := range params.Tags {
+					tags[key] = value
+				}
+			})
+
+			return &emrcontainers.TagResourceOutput{}, nil
+		},
+		UntagResourceFunc: func(ctx context.Context, params *emrcontainers.UntagResourceInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.UntagResourceOutput, error) {
+			f.updateTags(aws.ToString(params.ResourceArn), func(tags map[string]string) {
+				for _, key := range params.TagKeys {
+					delete(tags, key)
+				}
+			})
+
+			return &emrcontainers.UntagResourceOutput{}, nil
+		},
+	}
+}
+
+func (f *fakeAWS) createJobTemplate(ctx context.Context, params *emrcontainers.CreateJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.CreateJobTemplateOutput, error) {
+	if f.FailCreate != nil {
+		return nil, f.FailCreate
+	}
+	// The client token makes creating the same content twice return the first template.
+	f.mu.Lock()
+	id, ok := f.tokens[aws.ToString(params.ClientToken)]
+	if !ok {
+		f.created++
+		id = fmt.Sprintf("jt-%d", f.created)
+		f.tokens[aws.ToString(params.ClientToken)] = id
+	}
+	f.mu.Unlock()
+	if !ok {
+		f.addTemplate(id, aws.ToString(params.Name), params.JobTemplateData, params.Tags)
+	}
+
+	return &emrcontainers.CreateJobTemplateOutput{Id: aws.String(id)}, nil
+}
+
+func (f *fakeAWS) describeJobTemplate(ctx context.Context, params *emrcontainers.DescribeJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobTemplateOutput, error) {
+	if err := f.FailDescribe[aws.ToString(params.Id)]; err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	jobTemplate := f.template(aws.ToString(params.Id))
+	if jobTemplate == nil {
+		return nil, &emrtypes.ResourceNotFoundException{Message: aws.String("job template not found")}
+	}
+	described := *jobTemplate
+
+	return &emrcontainers.DescribeJobTemplateOutput{JobTemplate: &described}, nil
+}
+
+func (f *fakeAWS) deleteJobTemplate(ctx context.Context, params *emrcontainers.DeleteJobTemplateInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DeleteJobTemplateOutput, error) {
+	id := aws.ToString(params.Id)
+	if err := f.FailDelete[id]; err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.removeTemplate(id)
+	f.Deleted = append(f.Deleted, id)
+
+	return &emrcontainers.DeleteJobTemplateOutput{Id: params.Id}, nil
+}
+
+func (f *fakeAWS) startJobRun(ctx context.Context, params *emrcontainers.StartJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.StartJobRunOutput, error) {
+	if f.FailStart != nil {
+		return nil, f.FailStart
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Started = append(f.Started, params)
+
+	return &emrcontainers.StartJobRunOutput{Id: aws.String(fmt.Sprintf("jr-%d", len(f.Started)))}, nil
+}
+
+// describeJobRun reports JobRunStates one by one, staying in the last one.
+func (f *fakeAWS) describeJobRun(ctx context.Context, params *emrcontainers.DescribeJobRunInput, optFns ...func(*emrcontainers.Options)) (*emrcontainers.DescribeJobRunOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state := emrtypes.JobRunStateRunning
+	if len(f.JobRunStates) > 0 {
+		state = f.JobRunStates[0]
+	}
+	if len(f.JobRunStates) > 1 {
+		f.JobRunStates = f.JobRunStates[1:]
+	}
+
+	return &emrcontainers.DescribeJobRunOutput{JobRun: &emrtypes.JobRun{Id: params.Id, State: state}}, nil
+}
+
+// updateTags changes the tags of the job template with the ARN.
+func (f *fakeAWS) updateTags(arn string, update func(tags map[string]string)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.Templates {
+		if aws.ToString(f.Templates[i].Arn) == arn {
+			update(f.Templates[i].Tags)
+		}
+	}
+}
+
+func (f *fakeAWS) ssm() *MockSSMClient {
+	return &MockSSMClient{
+		GetParameterFunc: func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			value, ok := f.Values[aws.ToString(params.Name)]
+			if !ok {
+				return nil, &ssmtypes.ParameterNotFound{}
+			}
+
+			return &ssm.GetParameterOutput{Parameter: &ssmtypes.Parameter{Name: params.Name, Value: aws.String(value)}}, nil
+		},
+		GetParameterHistoryFunc: func(ctx context.Context, params *ssm.GetParameterHistoryInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			var versions []ssmtypes.ParameterHistory
+			for _, value := range f.History[aws.ToString(params.Name)] {
+				versions = append(versions, ssmtypes.ParameterHistory{Name: params.Name, Value: aws.String(value)})
+			}
+
+			return &ssm.GetParameterHistoryOutput{Parameters: versions}, nil
+		},
+		PutParameterFunc: func(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+			name := aws.ToString(params.Name)
+			if err := f.FailPut[name]; err != nil {
+				return nil, err
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.Values[name] = aws.ToString(params.Value)
+			f.History[name] = append(f.History[name], aws.ToString(params.Value))
+
+			return &ssm.PutParameterOutput{}, nil
+		},
+		DeleteParameterFunc: func(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			delete(f.Values, aws.ToString(params.Name))
+
+			return &ssm.DeleteParameterOutput{}, nil
+		},
+	}
+}
+
+// testJobTemplate returns a valid job template config publishing its ID to the given SSM parameters.
+func testJobTemplate(name string, pmNames ...string) template.JobTemplateConfig {
+	jobTemplate := template.JobTemplateConfig{
+		Name:             name,
+		ExecutionRoleArn: "arn:aws:iam::123456789012:role/EMRExecutionRole",
+		ReleaseLabel:     "emr-6.4.0-latest",
+		EntryPoint:       "s3://bucket/script.py",
+		PersistentAppUI:  "ENABLED",
+		LogGroupName:     "/aws/emr-containers/jobs",
+		SparkSubmitParameters: template.SparkSubmitParameters{
+			Master: "yarn", DeployMode: "cluster", Class: "org.example.Main", Packages: "org.example:lib:1.0",
+			Conf: []string{"spark.executor.instances=${Executors}"},
+		},
+		ParameterConfiguration: map[string]template.TemplateParameterConfiguration{
+			"Executors": {DefaultValue: aws.String("2"), Type: emrtypes.TemplateParameterDataTypeNumber},
+		},
+	}
+	for _, pmName := range pmNames {
+		jobTemplate.SSMParameters = append(jobTemplate.SSMParameters, template.SSMParameter{Name: pmName})
+	}
+
+	return jobTemplate
+}
+
+// preparedJobTemplateData returns the job template data apply creates for a job template config.
+func preparedJobTemplateData(t *testing.T, jobTemplate template.JobTemplateConfig) *emrtypes.JobTemplateData {
+	t.Helper()
+	input, err := awsutils.PrepareJobTemplateInput(jobTemplate, &awsutils.RealParameterConfigurator{}, &awsutils.RealSparkSubmitCommandBuilder{}, &awsutils.RealClientTokenGenerator{})
+	require.NoError(t, err)
+
+	return input.JobTemplateData
+}
+
+// testLogger returns a logger discarding its output.
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return logger
+}
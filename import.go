@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// importTimeout bounds listing and describing the job templates of the account.
+const importTimeout = 5 * time.Minute
+
+// parseImportFlags parses "import [<template-name>...]". Without names every job template is imported.
+func parseImportFlags(args []string) ([]string, error) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+
+			return nil, fmt.Errorf("unknown flag %s, import only takes job template names", arg)
+		}
+	}
+
+	return args, nil
+}
+
+// selectImportTemplates returns the most recent job template of every requested name, sorted by
+// name. Older templates with the same name are superseded versions and are not imported.
+func selectImportTemplates(templates []types.JobTemplate, names []string) ([]types.JobTemplate, error) {
+	groups := awsutils.GroupJobTemplatesByName(templates)
+	if len(names) == 0 {
+		for name := range groups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	selected := make([]types.JobTemplate, 0, len(names))
+	for _, name := range names {
+		group, ok := groups[name]
+		if !ok {
+
+			return nil, fmt.Errorf("job template '%s' not found", name)
+		}
+		selected = append(selected, group[0])
+	}
+
+	return selected, nil
+}
+
+// runImport describes the selected job templates and prints them as a configuration file. Job
+// templates that cannot be expressed in the configuration are reported and left out.
+func runImport(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, names []string, w io.Writer) error {
+	templates, err := awsutils.ListJobTemplates(ctx, clients.EMRContainers)
+	if err != nil {
+
+		return err
+	}
+	selected, err := selectImportTemplates(templates, names)
+	if err != nil {
+
+		return err
+	}
+
+	config := &template.Config{JobTemplates: make([]template.JobTemplateConfig, 0, len(selected))}
+	failed := 0
+	for _, listed := range selected {
+		jobTemplateID := aws.ToString(listed.Id)
+		described, err := awsutils.DescribeJobTemplate(ctx, clients.EMRContainers, jobTemplateID)
+		if err != nil {
+
+			return fmt.Errorf("job template '%s' (%s): %w", awsutils.JobTemplateName(listed), jobTemplateID, err)
+		}
+
+		jobConfig, err := awsutils.ImportJobTemplate(*described)
+		if err != nil {
+			logger.Errorf("Skipping job template %s: %v", jobTemplateID, err)
+			failed++
+
+			continue
+		}
+		logger.Infof("Imported job template '%s' (%s)", jobConfig.Name, jobTemplateID)
+		config.JobTemplates = append(config.JobTemplates, jobConfig)
+	}
+
+	// Templates created by hand may not pass our validation; apply would refuse them as they are.
+	for _, validationError := range template.Validate(config) {
+		logger.Warnf("Imported configuration: %s", validationError)
+	}
+
+	if err := writeRender(w, config); err != nil {
+
+		return err
+	}
+	if failed > 0 {
+
+		return fmt.Errorf("%d of %d job templates could not be imported", failed, len(selected))
+	}
+
+	return nil
+}
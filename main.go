@@ -5,53 +5,242 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math/rand"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
 	"github.com/sirupsen/logrus"
 
 	"github.com/GoGstickGo/emr-containers-template/awsutils"
 	"github.com/GoGstickGo/emr-containers-template/template"
 )
 
+// commands lists the supported commands; apply is the default.
+var commands = []string{"apply", "import", "plan", "prune", "render", "rollback", "run", "schema", "validate"}
+
 // Config holds the application configuration.
 type Config struct {
-	AWSRegion string
-	PathYAML  string
-	PmNames   []string
+	AWSRegion        string
+	PathYAML         string
+	PmNames          []string
+	PruneKeep        int
+	PruneMinAge      time.Duration
+	PruneAfterApply  bool
+	Concurrency      int
+	TemplateTimeout  time.Duration
+	RollbackDelete   bool
+	VirtualClusterID string
+	JobRunTimeout    time.Duration
+	Environment      string
+	Retry            awsutils.RetryPolicy
+}
+
+// splitEnvFlag removes the --env flag, accepted by every command, from the command arguments.
+func splitEnvFlag(args []string) (environment string, rest []string, err error) {
+	rest = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--env" && name != "-env" {
+			rest = append(rest, args[i])
+
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+
+				return "", nil, errors.New("--env needs an environment name")
+			}
+			i++
+			value = args[i]
+		}
+		environment = value
+	}
+
+	return environment, rest, nil
+}
+
+// commandsWithoutArgs lists the commands that take no arguments besides --env.
+var commandsWithoutArgs = map[string]bool{"apply": true, "plan": true, "render": true, "schema": true, "validate": true}
+
+// parseCommandLine removes the --env flag, wherever it is, and then selects the command,
+// defaulting to apply, so "--env prod plan" plans instead of applying.
+func parseCommandLine(args []string) (command, environment string, rest []string, err error) {
+	environment, rest, err = splitEnvFlag(args)
+	if err != nil {
+
+		return "", "", nil, err
+	}
+
+	command = "apply"
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		command, rest = rest[0], rest[1:]
+	}
+	if !slices.Contains(commands, command) {
+
+		return "", "", nil, fmt.Errorf("unknown command '%s', expected one of: %s", command, strings.Join(commands, ", "))
+	}
+	if commandsWithoutArgs[command] && len(rest) > 0 {
+
+		return "", "", nil, fmt.Errorf("%s takes no arguments, got: %s", command, strings.Join(rest, " "))
+	}
+
+	return command, environment, rest, nil
 }
 
 // loadConfigFromEnv loads and validates configuration from environment variables.
+// SSM_PM_NAMES is optional; it is the fallback for templates without ssm_parameters.
 func loadConfigFromEnv(logger *logrus.Logger) (cfg Config, err error) {
-	ssmStringList := os.Getenv("SSM_PM_NAMES") // Must be set; no default.
-	var ssmList []string
+	ssmList, err := parsePmNames(os.Getenv("SSM_PM_NAMES"))
+	if err != nil {
+		logger.Error(err)
 
-	if ssmStringList == "" {
-		errMsg := "SSM parameter name must be defined"
-		logger.Error(errMsg)
+		return cfg, err
+	}
+
+	pruneKeep, err := getEnvInt("PRUNE_KEEP", 5)
+	if err != nil {
+		logger.Error(err)
+
+		return cfg, err
+	}
+
+	pruneMinAge, err := getEnvDuration("PRUNE_MIN_AGE", 24*time.Hour)
+	if err != nil {
+		logger.Error(err)
+
+		return cfg, err
+	}
+
+	pruneAfterApply, err := getEnvBool("PRUNE_AFTER_APPLY", false)
+	if err != nil {
+		logger.Error(err)
 
-		return cfg, errors.New(errMsg)
+		return cfg, err
 	}
 
-	if strings.Contains(ssmStringList, ",") {
-		ssmList = strings.Split(ssmStringList, ",")
-	} else {
-		ssmList = append(ssmList, ssmStringList)
+	concurrency, err := getEnvInt("CONCURRENCY", 4)
+	if err == nil && concurrency < 1 {
+		err = errors.New("CONCURRENCY must be at least 1")
+	}
+	if err != nil {
+		logger.Error(err)
+
+		return cfg, err
+	}
+
+	templateTimeout, err := getEnvDuration("TEMPLATE_TIMEOUT", 2*time.Minute)
+	if err != nil {
+		logger.Error(err)
+
+		return cfg, err
+	}
+
+	rollbackDelete, err := getEnvBool("ROLLBACK_DELETE_TEMPLATE", false)
+	if err != nil {
+		logger.Error(err)
+
+		return cfg, err
+	}
+
+	jobRunTimeout, err := getEnvDuration("JOB_RUN_TIMEOUT", time.Hour)
+	if err != nil {
+		logger.Error(err)
+
+		return cfg, err
+	}
+
+	retry, err := loadRetryPolicyFromEnv()
+	if err != nil {
+		logger.Error(err)
+
+		return cfg, err
 	}
 
 	cfg = Config{
-		AWSRegion: getEnv("AWS_REGION", "us-east-1"),
-		PathYAML:  getEnv("PATH_YAML", "example.yaml"),
-		PmNames:   ssmList,
+		AWSRegion:        getEnv("AWS_REGION", "us-east-1"),
+		PathYAML:         getEnv("PATH_YAML", "example.yaml"),
+		PmNames:          ssmList,
+		PruneKeep:        pruneKeep,
+		PruneMinAge:      pruneMinAge,
+		PruneAfterApply:  pruneAfterApply,
+		Concurrency:      concurrency,
+		TemplateTimeout:  templateTimeout,
+		RollbackDelete:   rollbackDelete,
+		VirtualClusterID: getEnv("VIRTUAL_CLUSTER_ID", ""),
+		JobRunTimeout:    jobRunTimeout,
+		Environment:      getEnv("EMR_ENV", ""),
+		Retry:            retry,
 	}
 
-	logger.Infof("Loaded configuration: %+v", cfg)
+	// The retry policy holds funcs, so the fields are listed rather than the struct printed.
+	logger.Infof("Loaded configuration: region=%s path=%s parameters=%v environment=%q concurrency=%d template-timeout=%s "+
+		"rollback-delete=%t prune-keep=%d prune-min-age=%s prune-after-apply=%t virtual-cluster=%q job-run-timeout=%s "+
+		"retry-attempts=%d retry-base-delay=%s retry-max-delay=%s",
+		cfg.AWSRegion, cfg.PathYAML, cfg.PmNames, cfg.Environment, cfg.Concurrency, cfg.TemplateTimeout,
+		cfg.RollbackDelete, cfg.PruneKeep, cfg.PruneMinAge, cfg.PruneAfterApply, cfg.VirtualClusterID, cfg.JobRunTimeout,
+		cfg.Retry.MaxAttempts, cfg.Retry.BaseDelay, cfg.Retry.MaxDelay)
 
 	return cfg, nil
 }
 
+// parsePmNames splits a comma separated list of SSM parameter names.
+func parsePmNames(ssmStringList string) ([]string, error) {
+	if ssmStringList == "" {
+
+		return nil, nil
+	}
+
+	ssmList := strings.Split(ssmStringList, ",")
+	for _, pmName := range ssmList {
+		if strings.TrimSpace(pmName) == "" {
+
+			return nil, errors.New("SSM parameter names must not be empty")
+		}
+	}
+
+	return ssmList, nil
+}
+
+// loadRetryPolicyFromEnv reads the AWS call retry policy, starting from the awsutils defaults.
+func loadRetryPolicyFromEnv() (awsutils.RetryPolicy, error) {
+	policy := awsutils.DefaultRetryPolicy()
+
+	maxAttempts, err := getEnvInt("AWS_RETRY_MAX_ATTEMPTS", policy.MaxAttempts)
+	if err != nil {
+
+		return policy, err
+	}
+	if maxAttempts < 1 {
+
+		return policy, errors.New("AWS_RETRY_MAX_ATTEMPTS must be at least 1")
+	}
+
+	baseDelay, err := getEnvDuration("AWS_RETRY_BASE_DELAY", policy.BaseDelay)
+	if err != nil {
+
+		return policy, err
+	}
+
+	maxDelay, err := getEnvDuration("AWS_RETRY_MAX_DELAY", policy.MaxDelay)
+	if err != nil {
+
+		return policy, err
+	}
+	if maxDelay < baseDelay {
+
+		return policy, errors.New("AWS_RETRY_MAX_DELAY must not be shorter than AWS_RETRY_BASE_DELAY")
+	}
+
+	policy.MaxAttempts, policy.BaseDelay, policy.MaxDelay = maxAttempts, baseDelay, maxDelay
+
+	return policy, nil
+}
+
 // getEnv retrieves the value of the environment variable named by the key.
 // If the variable is not present, it returns the provided default value.
 func getEnv(key, defaultVal string) string {
@@ -63,25 +252,140 @@ func getEnv(key, defaultVal string) string {
 	return defaultVal
 }
 
+// getEnvInt retrieves an integer environment variable, or the default value when unset.
+func getEnvInt(key string, defaultVal int) (int, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+
+		return defaultVal, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+// getEnvDuration retrieves a duration environment variable such as "24h", or the default value when unset.
+func getEnvDuration(key string, defaultVal time.Duration) (time.Duration, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+
+		return defaultVal, nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+
+		return 0, fmt.Errorf("%s must be a duration: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+// getEnvBool retrieves a boolean environment variable, or the default value when unset.
+func getEnvBool(key string, defaultVal bool) (bool, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+
+		return defaultVal, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+
+		return false, fmt.Errorf("%s must be a boolean: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+// resolveSSMParameters maps SSM parameters to job templates. Templates declaring
+// ssm_parameters keep them, the others fall back to SSM_PM_NAMES by position.
+func resolveSSMParameters(jobTemplates []template.JobTemplateConfig, pmNames []string) error {
+	if len(pmNames) == 0 {
+
+		return nil
+	}
+
+	for i := range jobTemplates {
+		if jobTemplates[i].SSMParameters != nil {
+			continue
+		}
+		if len(jobTemplates) != len(pmNames) {
+
+			return errors.New("the number of job templates must match the number of SSM parameters in SSM_PM_NAMES, or each job template must declare ssm_parameters")
+		}
+		jobTemplates[i].SSMParameters = []template.SSMParameter{{Name: pmNames[i]}}
+	}
+
+	return nil
+}
+
 // processJobTemplate handles the entire lifecycle of a single job template.
-func processJobTemplate(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, jobTemplate template.JobTemplateConfig, cfg Config, random rand.Rand) error {
+// Once the template is created, any failure rolls back the SSM parameters written so far.
+func processJobTemplate(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, jobTemplate template.JobTemplateConfig, clientTokenGenerator awsutils.ClientTokenGenerator, opts applyOptions) error {
 	logger.Infof("Processing job template: %s", jobTemplate.Name)
 
 	// Initialize helper implementations using interfaces
 	var parameterConfigurator awsutils.ParameterConfigurator = &awsutils.RealParameterConfigurator{}
 	var sparkSubmitCommandBuilder awsutils.SparkSubmitCommandBuilder = &awsutils.RealSparkSubmitCommandBuilder{}
 
-	// Inject the actual random number generator.
-	randomIntn := random.Intn
-
 	// Prepare the job template input.
-	temp, err := awsutils.PrepareJobTemplateInput(jobTemplate, parameterConfigurator, sparkSubmitCommandBuilder, randomIntn)
+	temp, err := awsutils.PrepareJobTemplateInput(jobTemplate, parameterConfigurator, sparkSubmitCommandBuilder, clientTokenGenerator)
 	if err != nil {
 
 		return fmt.Errorf("error preparing job template '%s': %w", jobTemplate.Name, err)
 	}
 	logger.Infof("Prepared job template input for '%s'", jobTemplate.Name)
 
+	// Resolve the outputs before anything is created, so configuration problems fail early.
+	var outputs []awsutils.TemplateIDSink
+	if opts.Outputs != nil {
+		if outputs, err = opts.Outputs.forTemplate(jobTemplate); err != nil {
+
+			return err
+		}
+	}
+
+	var smokeRun awsutils.JobRunInput
+	if jobTemplate.SmokeTest != nil {
+		if smokeRun, err = smokeTestRun(jobTemplate, temp, opts.VirtualClusterID); err != nil {
+
+			return err
+		}
+	}
+
+	// Record the content fingerprint on the template, so the next apply recognises it without a field diff.
+	fingerprint := awsutils.SetFingerprintTag(temp)
+
+	var listed []types.JobTemplate
+	deployedID, diffs, err := compareDeployedJobTemplate(ctx, clients, jobTemplate, temp, fingerprint, &listed)
+	if err != nil {
+
+		return err
+	}
+
+	// Record the current SSM values before anything is written, so a failed update can be undone.
+	ssmTransaction := awsutils.NewSSMTransaction(clients.SSM)
+	if err := ssmTransaction.Prepare(ctx, jobTemplate.SSMParameters); err != nil {
+
+		return fmt.Errorf("failed to prepare SSM parameters of job template '%s': %w", jobTemplate.Name, err)
+	}
+
+	if deployedID != "" && len(diffs) == 0 {
+		logger.Infof("Job template '%s' unchanged, keeping %s", jobTemplate.Name, deployedID)
+
+		// Parameters already holding the ID are not written again, outputs such as GITHUB_OUTPUT
+		// are expected on every run. The deployed template is never deleted by a rollback.
+		opts.DeleteOnRollback = false
+
+		return publishJobTemplate(ctx, logger, clients, jobTemplate, deployedID, ssmTransaction, outputs, opts)
+	}
+
 	// Create the job template.
 	jobTemplateID, err := awsutils.CreateJobTemplate(ctx, clients.EMRContainers, temp)
 	if err != nil {
@@ -94,71 +398,316 @@ func processJobTemplate(ctx context.Context, logger *logrus.Logger, clients *aws
 	jobTemplateDesc, err := awsutils.DescribeJobTemplate(ctx, clients.EMRContainers, jobTemplateID)
 	if err != nil {
 
-		return fmt.Errorf("failed to describe job template '%s': %w", jobTemplate.Name, err)
+		return rollbackJobTemplate(ctx, logger, clients, jobTemplate.Name, jobTemplateID, ssmTransaction, opts,
+			fmt.Errorf("failed to describe job template '%s': %w", jobTemplate.Name, err))
 	}
 
 	// Marshal the job template description to JSON for logging.
 	jobTemplateJSON, err := json.MarshalIndent(jobTemplateDesc, "", "  ")
 	if err != nil {
 
-		return fmt.Errorf("failed to marshal job template '%s' to JSON: %w", jobTemplate.Name, err)
+		return rollbackJobTemplate(ctx, logger, clients, jobTemplate.Name, jobTemplateID, ssmTransaction, opts,
+			fmt.Errorf("failed to marshal job template '%s' to JSON: %w", jobTemplate.Name, err))
 	}
 
 	logger.Infof("Job template '%s' content:\n%s\n", jobTemplate.Name, string(jobTemplateJSON))
 
-	// Update the SSM parameter with the new job template ID.
-	for i := range cfg.PmNames {
-		if err = awsutils.UpdateSSMParameter(ctx, clients.SSM, cfg.PmNames[i], jobTemplateID); err != nil {
-			return fmt.Errorf("failed to update SSM parameter '%s': %w", cfg.PmNames[i], err)
+	// Only promote the new job template once a job run of it completed, the SSM parameters keep the old one otherwise.
+	if jobTemplate.SmokeTest != nil {
+		smokeRun.JobTemplateID = jobTemplateID
+		if err := smokeTestJobTemplate(ctx, logger, clients, jobTemplate, smokeRun, jobTemplateDesc); err != nil {
+
+			return err
 		}
-		logger.Infof("Updated SSM parameter '%s' with job template ID: %s", cfg.PmNames[i], jobTemplateID)
+	}
+
+	return publishJobTemplate(ctx, logger, clients, jobTemplate, jobTemplateID, ssmTransaction, outputs, opts)
+}
+
+// publishJobTemplate publishes the new job template ID, SSM parameters first since only they can
+// be rolled back. A failure rolls back the SSM parameters written so far.
+func publishJobTemplate(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, jobTemplate template.JobTemplateConfig, jobTemplateID string,
+	ssmTransaction *awsutils.SSMTransaction, outputs []awsutils.TemplateIDSink, opts applyOptions) error {
+	sinks, ssmSinks := outputs, 0
+	if len(jobTemplate.SSMParameters) > 0 {
+		sinks, ssmSinks = append([]awsutils.TemplateIDSink{&awsutils.SSMSink{Transaction: ssmTransaction}}, outputs...), 1
+	}
+	published, err := publishTemplateID(ctx, logger, sinks, jobTemplate.Name, jobTemplateID)
+	if err == nil {
+
+		return nil
+	}
+
+	// Files and stdout keep what was written, only the SSM parameters are rolled back.
+	if len(published) > ssmSinks {
+		logger.Warnf("Outputs of job template '%s' already published are not rolled back: %s", jobTemplate.Name, strings.Join(published[ssmSinks:], ", "))
+	}
+
+	return rollbackJobTemplate(ctx, logger, clients, jobTemplate.Name, jobTemplateID, ssmTransaction, opts, err)
+}
+
+// newAWSClients initializes the AWS clients. Retries are handled by our policy, so the SDK makes a single attempt.
+func newAWSClients(ctx context.Context, logger *logrus.Logger, cfg Config) (*awsutils.AWSClients, error) {
+	configLoader := &awsutils.RealAWSConfigLoader{RetryMaxAttempts: 1}
+	clients, err := awsutils.InitializeAWSClients(ctx, configLoader, cfg.AWSRegion)
+	if err != nil {
+
+		return nil, err
+	}
+	cfg.Retry.OnRetry = func(attempt int, delay time.Duration, err error) {
+		logger.Warnf("AWS call failed (attempt %d), retrying in %s: %v", attempt, delay.Round(time.Millisecond), err)
+	}
+	logger.Info("AWS clients initialized successfully")
+
+	return awsutils.WithRetries(clients, cfg.Retry), nil
+}
+
+// errConfigInvalid is returned by validate when the configuration has errors; they are already printed.
+var errConfigInvalid = errors.New("configuration is invalid")
+
+// commandEnv is what the commands working on deployed job templates get from main.
+type commandEnv struct {
+	logger       *logrus.Logger
+	cfg          Config
+	clients      *awsutils.AWSClients
+	jobTemplates []template.JobTemplateConfig
+}
+
+// awsCommands run the commands working on deployed job templates, once the configuration is
+// valid. ctx bounds client setup and the single shot commands, longer commands derive their own.
+var awsCommands = map[string]func(ctx context.Context, env commandEnv, args []string) error{
+	"apply":    applyCommand,
+	"plan":     planCommand,
+	"prune":    pruneCommand,
+	"rollback": rollbackCommand,
+	"run":      runCommand,
+}
+
+// planCommand reports the differences between the configuration and the deployed job templates.
+func planCommand(ctx context.Context, env commandEnv, _ []string) error {
+	if err := runPlan(ctx, env.logger, env.clients, env.jobTemplates, os.Stdout); err != nil {
+
+		return fmt.Errorf("planning failed: %w", err)
 	}
 
 	return nil
 }
 
-func main() {
-	// Set seed for random number generator.
-	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+// pruneCommand deletes superseded job templates.
+func pruneCommand(ctx context.Context, env commandEnv, args []string) error {
+	opts, err := parsePruneFlags(args, env.cfg)
+	if err != nil {
 
-	// Initialize logger.
-	logger := logrus.New()
-	logger.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-	})
+		return fmt.Errorf("invalid prune arguments: %w", err)
+	}
+	if err := runPrune(ctx, env.logger, env.clients, env.jobTemplates, opts, os.Stdout); err != nil {
+
+		return fmt.Errorf("pruning failed: %w", err)
+	}
+
+	return nil
+}
+
+// rollbackCommand repoints the SSM parameters to an earlier job template.
+func rollbackCommand(ctx context.Context, env commandEnv, args []string) error {
+	opts, err := parseRollbackFlags(args)
+	if err != nil {
+
+		return fmt.Errorf("invalid rollback arguments: %w", err)
+	}
+	if err := runRollback(ctx, env.logger, env.clients, env.jobTemplates, opts, os.Stdout); err != nil {
+
+		return fmt.Errorf("rollback failed: %w", err)
+	}
+
+	return nil
+}
+
+// runCommand starts a job run from a deployed job template.
+func runCommand(_ context.Context, env commandEnv, args []string) error {
+	opts, err := parseRunFlags(args, env.cfg)
+	if err != nil {
+
+		return fmt.Errorf("invalid run arguments: %w", err)
+	}
+	// Waiting can take much longer than the root context, and an interrupt stops the wait.
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := runRun(runCtx, env.logger, env.clients, env.jobTemplates, opts, os.Stdout); err != nil {
+
+		return fmt.Errorf("run failed: %w", err)
+	}
+
+	return nil
+}
+
+// applyCommand processes the job templates in parallel, each with its own timeout, deriving client
+// tokens from the template content, and optionally prunes the templates superseded by this run.
+func applyCommand(_ context.Context, env commandEnv, _ []string) error {
+	if err := runApply(context.Background(), env.logger, env.clients, env.jobTemplates, env.cfg, &awsutils.RealClientTokenGenerator{}, os.Stdout); err != nil {
+
+		return fmt.Errorf("processing failed: %w", err)
+	}
+	if !env.cfg.PruneAfterApply {
+
+		return nil
+	}
+
+	// The apply may have outlived the root context, so pruning gets a fresh timeout.
+	pruneCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := pruneOptions{Keep: env.cfg.PruneKeep, MinAge: env.cfg.PruneMinAge}
+	if err := runPrune(pruneCtx, env.logger, env.clients, env.jobTemplates, opts, os.Stdout); err != nil {
+
+		return fmt.Errorf("pruning failed: %w", err)
+	}
+
+	return nil
+}
+
+// importCommand writes a configuration from the deployed job templates instead of reading one.
+func importCommand(logger *logrus.Logger, cfg Config, args []string) error {
+	names, err := parseImportFlags(args)
+	if err != nil {
+
+		return fmt.Errorf("invalid import arguments: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	clients, err := newAWSClients(ctx, logger, cfg)
+	if err != nil {
+
+		return fmt.Errorf("AWS auth error: %w", err)
+	}
+	if err := runImport(ctx, logger, clients, names, os.Stdout); err != nil {
+
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	return nil
+}
+
+// logValidationErrors logs the validation errors and warnings and fails if there are errors.
+func logValidationErrors(logger *logrus.Logger, validationErrors []template.ValidationError) error {
+	for _, validationError := range validationErrors {
+		if validationError.Warning {
+			logger.Warn(validationError)
+		} else {
+			logger.Error(validationError)
+		}
+	}
+	if errorCount := template.CountErrors(validationErrors); errorCount > 0 {
+
+		return fmt.Errorf("configuration has %d validation errors", errorCount)
+	}
+
+	return nil
+}
+
+// runCommandLine loads what the command needs and runs it.
+func runCommandLine(logger *logrus.Logger, command, environment string, args []string) error {
+	// The schema only depends on the configuration types, not on the environment or the YAML file.
+	if command == "schema" {
+
+		return writeSchema(os.Stdout)
+	}
 
 	// Load configuration.
 	cfg, err := loadConfigFromEnv(logger)
 	if err != nil {
-		logger.Fatal(err)
+
+		return err
+	}
+	if environment != "" {
+		cfg.Environment = environment
+	}
+
+	if command == "import" {
+
+		return importCommand(logger, cfg, args)
 	}
 
-	// Create a root context with a timeout for the entire application run.
+	// Load and validate job templates before any AWS client is created.
+	jobConfigs, validationErrors, err := loadJobTemplates(logger, cfg)
+	if err != nil {
+
+		return fmt.Errorf("error loading YAML config file: %w", err)
+	}
+
+	switch command {
+	case "render":
+		// Only print the resolved job templates when rendering.
+		if err := writeRender(os.Stdout, jobConfigs); err != nil {
+
+			return fmt.Errorf("rendering failed: %w", err)
+		}
+
+		return nil
+	case "validate":
+		// Only report the validation result when validating.
+		if !writeValidation(os.Stdout, jobConfigs, validationErrors) {
+
+			return errConfigInvalid
+		}
+
+		return nil
+	}
+
+	if err := logValidationErrors(logger, validationErrors); err != nil {
+
+		return err
+	}
+
+	// Create a root context with a timeout for client setup and the single shot commands.
 	ctxTimeOut, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Initialize AWS clients.
-	configLoader := &awsutils.RealAWSConfigLoader{}
-	clients, err := awsutils.InitializeAWSClients(ctxTimeOut, configLoader, cfg.AWSRegion)
+	clients, err := newAWSClients(ctxTimeOut, logger, cfg)
 	if err != nil {
-		logger.Fatalf("AWS auth error: %v", err)
+
+		return fmt.Errorf("AWS auth error: %w", err)
 	}
-	logger.Info("AWS clients initialized successfully")
 
-	// Load job templates from YAML configuration.
-	jobConfigs, err := template.LoadConfig(cfg.PathYAML)
-	if err != nil {
-		logger.Fatalf("Error loading YAML config file: %v", err)
+	env := commandEnv{logger: logger, cfg: cfg, clients: clients, jobTemplates: jobConfigs.JobTemplates}
+
+	return awsCommands[command](ctxTimeOut, env, args)
+}
+
+// exitStatus returns the exit status of a failed command.
+func exitStatus(err error) int {
+	if errors.Is(err, errRollbackIncomplete) {
+
+		return exitRollbackIncomplete
 	}
-	logger.Infof("Loaded %d job templates from configuration", len(jobConfigs.JobTemplates))
 
-	if len(jobConfigs.JobTemplates) != len(cfg.PmNames) {
-		logger.Fatalf("Error: The number of job templates must match the number of SSM parameters. Please review your job template configurations and corresponding SSM parameters.")
+	return 1
+}
+
+func main() {
+	// Initialize logger.
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: true,
+	})
+	template.SetLogger(logger)
+
+	// Select the command to run, defaulting to apply.
+	command, environment, args, err := parseCommandLine(os.Args[1:])
+	if err != nil {
+		logger.Fatal(err)
 	}
-	// Process each job template.
-	for _, jobTemplate := range jobConfigs.JobTemplates {
-		if err := processJobTemplate(ctxTimeOut, logger, clients, jobTemplate, cfg, *random); err != nil {
-			logger.Fatalf("Processing failed: %v", err)
+
+	if err := runCommandLine(logger, command, environment, args); err != nil {
+		switch {
+		case errors.Is(err, errConfigInvalid):
+			// writeValidation already printed the problems.
+		case errors.Is(err, errRollbackIncomplete):
+			logger.Errorf("SSM parameters or job templates need manual repair: %v", err)
+		default:
+			logger.Error(err)
 		}
+		os.Exit(exitStatus(err))
 	}
 }
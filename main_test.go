@@ -1,12 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
 )
 
 func TestLoadConfigFromEnv(t *testing.T) {
@@ -90,13 +103,533 @@ func TestLoadConfigFromEnv_MissingSSMName(t *testing.T) {
 	} else {
 		defer os.Unsetenv("SSM_PM_NAMES")
 	}
-	// Unset SSM_NAME to simulate missing configuration
+	// Unset SSM_NAME, the job templates may declare their own ssm_parameters.
 	os.Unsetenv("SSM_PM_NAMES")
 	// Call loadConfigFromEnv
 	cfg, err := loadConfigFromEnv(logger)
 
 	// Assertions
-	require.Error(t, err, "Expected an error due to missing SSM_PM_NAMES")
-	assert.Equal(t, "SSM parameter name must be defined", err.Error())
+	require.NoError(t, err, "SSM_PM_NAMES is an optional fallback")
 	assert.Empty(t, cfg.PmNames, "Expected SSMName to be empty")
 }
+
+func TestParsePmNames(t *testing.T) {
+	t.Parallel()
+
+	pmNames, err := parsePmNames("")
+	require.NoError(t, err)
+	assert.Empty(t, pmNames)
+
+	pmNames, err = parsePmNames("test-ssm,test-ssm2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test-ssm", "test-ssm2"}, pmNames)
+
+	_, err = parsePmNames("test-ssm,,test-ssm3")
+	require.Error(t, err)
+	assert.Equal(t, "SSM parameter names must not be empty", err.Error())
+}
+
+func TestResolveSSMParameters(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		jobTemplates []template.JobTemplateConfig
+		pmNames      []string
+		want         [][]string
+		wantErr      bool
+	}{
+		{
+			name: "Declared Parameters Win",
+			jobTemplates: []template.JobTemplateConfig{
+				{Name: "first", SSMParameters: []template.SSMParameter{{Name: "/first/a"}, {Name: "/first/b"}}},
+				{Name: "second", SSMParameters: []template.SSMParameter{}},
+			},
+			pmNames: []string{"env-1"},
+			want:    [][]string{{"/first/a", "/first/b"}, {}},
+		},
+		{
+			name: "Positional Fallback",
+			jobTemplates: []template.JobTemplateConfig{
+				{Name: "first"},
+				{Name: "second", SSMParameters: []template.SSMParameter{{Name: "/second"}}},
+			},
+			pmNames: []string{"env-1", "env-2"},
+			want:    [][]string{{"env-1"}, {"/second"}},
+		},
+		{
+			name: "No Fallback",
+			jobTemplates: []template.JobTemplateConfig{
+				{Name: "first"},
+			},
+			want: [][]string{{}},
+		},
+		{
+			name: "Fallback Count Mismatch",
+			jobTemplates: []template.JobTemplateConfig{
+				{Name: "first"},
+				{Name: "second"},
+			},
+			pmNames: []string{"env-1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := resolveSSMParameters(tt.jobTemplates, tt.pmNames)
+			if tt.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+			require.NoError(t, err)
+			for i, jobTemplate := range tt.jobTemplates {
+				assert.Equal(t, tt.want[i], jobTemplate.SSMParameterNames(), jobTemplate.Name)
+			}
+		})
+	}
+}
+
+func TestParsePruneFlags(t *testing.T) {
+	t.Parallel()
+	cfg := Config{PruneKeep: 5, PruneMinAge: 24 * time.Hour}
+
+	opts, err := parsePruneFlags([]string{}, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, pruneOptions{Keep: 5, MinAge: 24 * time.Hour}, opts)
+
+	opts, err = parsePruneFlags([]string{"--keep", "2", "--min-age", "1h", "--dry-run"}, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, pruneOptions{Keep: 2, MinAge: time.Hour, DryRun: true}, opts)
+
+	_, err = parsePruneFlags([]string{"--keep", "0"}, cfg)
+	require.Error(t, err)
+}
+
+func TestParseRollbackFlags(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseRollbackFlags([]string{"my-job"})
+	require.NoError(t, err)
+	assert.Equal(t, rollbackOptions{Name: "my-job", Target: "previous"}, opts)
+
+	opts, err = parseRollbackFlags([]string{"my-job", "--to", "abc123", "--dry-run"})
+	require.NoError(t, err)
+	assert.Equal(t, rollbackOptions{Name: "my-job", Target: "abc123", DryRun: true}, opts)
+
+	opts, err = parseRollbackFlags([]string{"--to", "abc123", "my-job"})
+	require.NoError(t, err)
+	assert.Equal(t, rollbackOptions{Name: "my-job", Target: "abc123"}, opts)
+
+	_, err = parseRollbackFlags([]string{})
+	require.Error(t, err)
+
+	_, err = parseRollbackFlags([]string{"my-job", "extra"})
+	require.Error(t, err)
+}
+
+func TestWriteValidation(t *testing.T) {
+	t.Parallel()
+	jobConfigs := &template.Config{JobTemplates: []template.JobTemplateConfig{{Name: "first"}, {Name: "second"}}}
+
+	var valid bytes.Buffer
+	assert.True(t, writeValidation(&valid, jobConfigs, nil))
+	assert.Equal(t, "2 job templates are valid\n", valid.String())
+
+	var invalid bytes.Buffer
+	validationErrors := []template.ValidationError{{Template: "first", Field: "name", Message: "must be set"}}
+	assert.False(t, writeValidation(&invalid, jobConfigs, validationErrors))
+	assert.Equal(t, "error: job template \"first\": name: must be set\n1 errors found in 2 job templates\n", invalid.String())
+
+	var warned bytes.Buffer
+	warnings := []template.ValidationError{{Template: "second", Field: "parameter_configuration.Dummy", Message: "parameter is declared but never referenced", Warning: true}}
+	assert.True(t, writeValidation(&warned, jobConfigs, warnings))
+	assert.Equal(t, "warning: job template \"second\": parameter_configuration.Dummy: parameter is declared but never referenced\n2 job templates are valid\n", warned.String())
+}
+
+// fixedTimeout gives every job template the same timeout.
+func fixedTimeout(timeout time.Duration) func(template.JobTemplateConfig) time.Duration {
+	return func(template.JobTemplateConfig) time.Duration { return timeout }
+}
+
+func TestRunPool(t *testing.T) {
+	t.Parallel()
+	jobTemplates := []template.JobTemplateConfig{{Name: "slow"}, {Name: "fails"}, {Name: "fast"}, {Name: "timeout"}, {Name: "last"}}
+
+	var running, maxRunning atomic.Int32
+	results := runPool(context.Background(), jobTemplates, 2, fixedTimeout(50*time.Millisecond), func(ctx context.Context, jobTemplate template.JobTemplateConfig) error {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			seen := maxRunning.Load()
+			if current <= seen || maxRunning.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+
+		switch jobTemplate.Name {
+		case "slow":
+			time.Sleep(20 * time.Millisecond)
+		case "fails":
+			return errors.New("create failed")
+		case "timeout":
+			<-ctx.Done()
+
+			return ctx.Err()
+		}
+
+		return nil
+	})
+
+	require.Len(t, results, len(jobTemplates))
+	for i, result := range results {
+		assert.Equal(t, jobTemplates[i].Name, result.Name)
+	}
+	require.NoError(t, results[0].Err)
+	require.EqualError(t, results[1].Err, "create failed")
+	require.NoError(t, results[2].Err)
+	require.ErrorIs(t, results[3].Err, context.DeadlineExceeded)
+	require.NoError(t, results[4].Err)
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+}
+
+func TestRunPool_Empty(t *testing.T) {
+	t.Parallel()
+
+	results := runPool(context.Background(), nil, 4, fixedTimeout(time.Second), func(context.Context, template.JobTemplateConfig) error {
+		return nil
+	})
+
+	assert.Empty(t, results)
+}
+
+func TestDefaultOutputKey(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "CUSTOM_JOB_TEMPLATE_ID", defaultOutputKey("custom-job"))
+	assert.Equal(t, "ETL_V2_DAILY_TEMPLATE_ID", defaultOutputKey("etl.v2 daily"))
+	assert.Equal(t, "_9LIVES_TEMPLATE_ID", defaultOutputKey("9lives"))
+}
+
+func TestOutputSinks_ForTemplate(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	outputs := newOutputSinks(&out)
+	outputs.getenv = func(key string) string {
+		if key == "GITHUB_OUTPUT" {
+			return "/tmp/github_output"
+		}
+
+		return ""
+	}
+
+	first, err := outputs.forTemplate(template.JobTemplateConfig{Name: "first", Outputs: []template.OutputConfig{
+		{Type: template.OutputTypeManifest, Path: "out/templates.yml"},
+		{Type: template.OutputTypeDotenv, PathEnv: "GITHUB_OUTPUT"},
+		{Type: template.OutputTypeStdout},
+	}})
+	require.NoError(t, err)
+	require.Len(t, first, 3)
+	assert.Equal(t, "yaml manifest out/templates.yml", first[0].String())
+	assert.Equal(t, "dotenv /tmp/github_output (FIRST_TEMPLATE_ID)", first[1].String())
+	assert.Equal(t, "stdout", first[2].String())
+
+	second, err := outputs.forTemplate(template.JobTemplateConfig{Name: "second", Outputs: []template.OutputConfig{
+		{Type: template.OutputTypeManifest, Path: "./out/templates.yml"},
+	}})
+	require.NoError(t, err)
+	assert.Same(t, first[0], second[0], "templates sharing a manifest must share the sink")
+
+	_, err = outputs.forTemplate(template.JobTemplateConfig{Name: "third", Outputs: []template.OutputConfig{
+		{Type: template.OutputTypeDotenv, PathEnv: "MISSING"},
+	}})
+	require.EqualError(t, err, "output 1 of job template 'third': environment variable MISSING is not set")
+
+	_, err = outputs.forTemplate(template.JobTemplateConfig{Name: "fourth", Outputs: []template.OutputConfig{
+		{Type: template.OutputTypeManifest, Path: "out/templates.yml", Format: "json"},
+	}})
+	require.EqualError(t, err, "output 1 of job template 'fourth': manifest out/templates.yml is already written as yaml")
+}
+
+func TestParseRunFlags(t *testing.T) {
+	t.Parallel()
+	cfg := Config{VirtualClusterID: "vc-env", JobRunTimeout: time.Hour}
+
+	opts, err := parseRunFlags([]string{"my-job", "--param", "RunDate=2024-01-01", "--param", "Filter=a=b"}, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, runOptions{
+		Name:             "my-job",
+		VirtualClusterID: "vc-env",
+		JobRunName:       "my-job",
+		Parameters:       map[string]string{"RunDate": "2024-01-01", "Filter": "a=b"},
+		Timeout:          time.Hour,
+	}, opts)
+
+	opts, err = parseRunFlags([]string{"--virtual-cluster", "vc-flag", "--name", "smoke", "my-job"}, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "vc-flag", opts.VirtualClusterID)
+	assert.Equal(t, "smoke", opts.JobRunName)
+	assert.Empty(t, opts.Parameters)
+	assert.False(t, opts.Wait)
+
+	opts, err = parseRunFlags([]string{"my-job", "--wait", "--timeout", "90m", "--cancel-on-interrupt"}, cfg)
+	require.NoError(t, err)
+	assert.True(t, opts.Wait)
+	assert.True(t, opts.CancelOnInterrupt)
+	assert.Equal(t, 90*time.Minute, opts.Timeout)
+
+	_, err = parseRunFlags([]string{"my-job", "--wait", "--timeout", "0s"}, cfg)
+	require.ErrorContains(t, err, "--timeout must be positive")
+
+	_, err = parseRunFlags([]string{"my-job"}, Config{})
+	require.ErrorContains(t, err, "virtual cluster ID is required")
+
+	_, err = parseRunFlags([]string{"my-job", "--param", "RunDate"}, cfg)
+	require.Error(t, err)
+
+	_, err = parseRunFlags([]string{"my-job", "--param", "A=1", "--param", "A=2"}, cfg)
+	require.Error(t, err)
+
+	_, err = parseRunFlags([]string{}, cfg)
+	require.Error(t, err)
+}
+
+func TestSmokeTestTimeout(t *testing.T) {
+	t.Parallel()
+	assert.Zero(t, smokeTestTimeout(template.JobTemplateConfig{}))
+	assert.Equal(t, smokeTestDefaultTimeout, smokeTestTimeout(template.JobTemplateConfig{SmokeTest: &template.SmokeTestConfig{}}))
+	assert.Equal(t, time.Minute, smokeTestTimeout(template.JobTemplateConfig{SmokeTest: &template.SmokeTestConfig{Timeout: time.Minute}}))
+}
+
+func TestSmokeTestRun(t *testing.T) {
+	t.Parallel()
+	input := &emrcontainers.CreateJobTemplateInput{
+		JobTemplateData: &types.JobTemplateData{
+			ParameterConfiguration: map[string]types.TemplateParameterConfiguration{
+				"MaxExecutors": {Type: types.TemplateParameterDataTypeNumber, DefaultValue: aws.String("10")},
+			},
+		},
+	}
+	jobTemplate := template.JobTemplateConfig{
+		Name:      "my-job",
+		SmokeTest: &template.SmokeTestConfig{Parameters: map[string]string{"MaxExecutors": "1"}},
+	}
+
+	run, err := smokeTestRun(jobTemplate, input, "vc-env")
+	require.NoError(t, err)
+	assert.Equal(t, awsutils.JobRunInput{Name: "my-job-smoke-test", VirtualClusterID: "vc-env", Parameters: map[string]string{"MaxExecutors": "1"}}, run)
+
+	jobTemplate.SmokeTest.VirtualClusterID = "vc-smoke"
+	run, err = smokeTestRun(jobTemplate, input, "vc-env")
+	require.NoError(t, err)
+	assert.Equal(t, "vc-smoke", run.VirtualClusterID)
+
+	_, err = smokeTestRun(template.JobTemplateConfig{Name: "my-job", SmokeTest: &template.SmokeTestConfig{}}, input, "")
+	require.ErrorContains(t, err, "needs a virtual cluster ID")
+
+	jobTemplate.SmokeTest.Parameters = map[string]string{"MaxExecutors": "many"}
+	_, err = smokeTestRun(jobTemplate, input, "")
+	require.ErrorContains(t, err, `parameter "MaxExecutors" must be a NUMBER`)
+}
+
+func TestWriteRender(t *testing.T) {
+	t.Parallel()
+	jobConfigs := &template.Config{JobTemplates: []template.JobTemplateConfig{{
+		Name:          "my-job",
+		ReleaseLabel:  "emr-6.4.0-latest",
+		SSMParameters: []template.SSMParameter{{Name: "/emr/my-job"}, {Name: "/emr/secure", Type: "SecureString"}},
+		SmokeTest:     &template.SmokeTestConfig{Timeout: 5 * time.Minute},
+	}}}
+
+	var out bytes.Buffer
+	require.NoError(t, writeRender(&out, jobConfigs))
+	assert.Equal(t, `job_templates:
+  - name: my-job
+    release_label: emr-6.4.0-latest
+    ssm_parameters:
+      - /emr/my-job
+      - name: /emr/secure
+        type: SecureString
+    smoke_test:
+      timeout: 5m0s
+`, out.String())
+}
+
+func TestWriteSchema(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, writeSchema(&out))
+
+	checkedIn, err := os.ReadFile(schemaFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(checkedIn), out.String(), "%s is out of date, regenerate it with: go run . schema > %s", schemaFile, schemaFile)
+}
+
+func TestSplitEnvFlag(t *testing.T) {
+	t.Parallel()
+
+	environment, rest, err := splitEnvFlag([]string{"my-job", "--env", "prod", "--wait"})
+	require.NoError(t, err)
+	assert.Equal(t, "prod", environment)
+	assert.Equal(t, []string{"my-job", "--wait"}, rest)
+
+	environment, rest, err = splitEnvFlag([]string{"-env=staging"})
+	require.NoError(t, err)
+	assert.Equal(t, "staging", environment)
+	assert.Empty(t, rest)
+
+	environment, rest, err = splitEnvFlag([]string{"--dry-run"})
+	require.NoError(t, err)
+	assert.Empty(t, environment)
+	assert.Equal(t, []string{"--dry-run"}, rest)
+
+	_, _, err = splitEnvFlag([]string{"--env"})
+	require.EqualError(t, err, "--env needs an environment name")
+}
+
+func TestParseCommandLine(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		args            []string
+		wantCommand     string
+		wantEnvironment string
+		wantRest        []string
+		wantErr         string
+	}{
+		{name: "Default Apply", args: nil, wantCommand: "apply", wantRest: []string{}},
+		{name: "Env After Command", args: []string{"plan", "--env", "prod"}, wantCommand: "plan", wantEnvironment: "prod", wantRest: []string{}},
+		{name: "Env Before Command", args: []string{"--env", "prod", "plan"}, wantCommand: "plan", wantEnvironment: "prod", wantRest: []string{}},
+		{name: "Env Equals Before Command", args: []string{"--env=prod", "validate"}, wantCommand: "validate", wantEnvironment: "prod", wantRest: []string{}},
+		{name: "Env Only", args: []string{"--env", "prod"}, wantCommand: "apply", wantEnvironment: "prod", wantRest: []string{}},
+		{
+			name: "Env Before Command With Args", args: []string{"--env", "prod", "rollback", "my-job", "--dry-run"},
+			wantCommand: "rollback", wantEnvironment: "prod", wantRest: []string{"my-job", "--dry-run"},
+		},
+		{
+			name: "Env Between Args", args: []string{"rollback", "my-job", "--env=prod", "--dry-run"},
+			wantCommand: "rollback", wantEnvironment: "prod", wantRest: []string{"my-job", "--dry-run"},
+		},
+		{name: "Unknown Command", args: []string{"--env", "prod", "deploy"}, wantErr: "unknown command 'deploy', expected one of: " + strings.Join(commands, ", ")},
+		{name: "Apply With Args", args: []string{"apply", "plan"}, wantErr: "apply takes no arguments, got: plan"},
+		{name: "Default Apply With Flag", args: []string{"--dry-run"}, wantErr: "apply takes no arguments, got: --dry-run"},
+		{name: "Plan With Args", args: []string{"plan", "my-job"}, wantErr: "plan takes no arguments, got: my-job"},
+		{name: "Validate With Args", args: []string{"--env", "prod", "validate", "extra"}, wantErr: "validate takes no arguments, got: extra"},
+		{name: "Render With Args", args: []string{"render", "--env", "prod", "extra"}, wantErr: "render takes no arguments, got: extra"},
+		{name: "Missing Env Value", args: []string{"plan", "--env"}, wantErr: "--env needs an environment name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			command, environment, rest, err := parseCommandLine(tt.args)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCommand, command)
+			assert.Equal(t, tt.wantEnvironment, environment)
+			assert.Equal(t, tt.wantRest, rest)
+		})
+	}
+}
+
+func TestParseImportFlags(t *testing.T) {
+	t.Parallel()
+
+	names, err := parseImportFlags([]string{"etl", "reports"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"etl", "reports"}, names)
+
+	_, err = parseImportFlags([]string{"etl", "--all"})
+	require.EqualError(t, err, "unknown flag --all, import only takes job template names")
+}
+
+func TestSelectImportTemplates(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	listed := func(id, name string, createdAt time.Time) types.JobTemplate {
+		return types.JobTemplate{Id: aws.String(id), Name: aws.String(name), CreatedAt: aws.Time(createdAt)}
+	}
+	templates := []types.JobTemplate{
+		listed("etl-old", "etl", now.Add(-time.Hour)),
+		listed("reports", "reports", now),
+		listed("etl-new", "etl", now),
+	}
+	ids := func(selected []types.JobTemplate) []string {
+		var ids []string
+		for _, jobTemplate := range selected {
+			ids = append(ids, aws.ToString(jobTemplate.Id))
+		}
+
+		return ids
+	}
+
+	selected, err := selectImportTemplates(templates, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"etl-new", "reports"}, ids(selected))
+
+	selected, err = selectImportTemplates(templates, []string{"reports"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reports"}, ids(selected))
+
+	_, err = selectImportTemplates(templates, []string{"missing"})
+	require.EqualError(t, err, "job template 'missing' not found")
+}
+
+func TestWriteRender_LoadsAgain(t *testing.T) {
+	t.Parallel()
+	jobConfigs, err := template.LoadConfig("template/testdata/environments.yaml", template.WithEnvironment("prod"))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, writeRender(&out, jobConfigs))
+	path := filepath.Join(t.TempDir(), "rendered.yaml")
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0o600))
+
+	// The rendered file has the overlay applied already and loads without an environment.
+	got, err := template.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Empty(t, got.Environment)
+	require.Len(t, got.JobTemplates, len(jobConfigs.JobTemplates))
+	for i := range got.JobTemplates {
+		// Only the position differs, it points into the rendered file.
+		got.JobTemplates[i].SourceFile, got.JobTemplates[i].SourceLine = jobConfigs.JobTemplates[i].SourceFile, jobConfigs.JobTemplates[i].SourceLine
+	}
+	assert.Equal(t, jobConfigs.JobTemplates, got.JobTemplates)
+}
+
+func TestRunImport_LoadsAgain(t *testing.T) {
+	t.Parallel()
+	fake := newFakeAWS(nil)
+	etl := testJobTemplate("etl")
+	etl.Tags = map[string]string{"Owner": "team-x"}
+	fake.addTemplate("jt-1", "etl", preparedJobTemplateData(t, testJobTemplate("etl")), nil)
+	fake.addTemplate("jt-2", "etl", preparedJobTemplateData(t, etl), nil)
+	fake.addTemplate("jt-3", "reports", preparedJobTemplateData(t, testJobTemplate("reports")), nil)
+
+	var out bytes.Buffer
+	require.NoError(t, runImport(context.Background(), testLogger(), fake.clients(), nil, &out))
+	path := filepath.Join(t.TempDir(), "imported.yaml")
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0o600))
+
+	// The most recent template of every name is imported and applying it would not change anything.
+	got, err := template.LoadConfig(path)
+	require.NoError(t, err)
+	require.Empty(t, template.Validate(got))
+	require.Len(t, got.JobTemplates, 2)
+	for i, want := range []template.JobTemplateConfig{etl, testJobTemplate("reports")} {
+		assert.Equal(t, preparedJobTemplateData(t, want), preparedJobTemplateData(t, got.JobTemplates[i]))
+	}
+}
+
+func TestExampleConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := template.LoadConfig("example.yaml")
+	require.NoError(t, err)
+	assert.Empty(t, template.Validate(config))
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// Plan actions reported for each job template.
+const (
+	planActionCreate    = "create"
+	planActionReplace   = "replace"
+	planActionUnchanged = "unchanged"
+)
+
+// templatePlan is the outcome of comparing one YAML job template with EMR on EKS.
+type templatePlan struct {
+	Name      string
+	Action    string
+	CurrentID string
+	Diffs     []awsutils.FieldDiff
+}
+
+// findCurrentJobTemplate locates the deployed template for a job template config.
+// The SSM parameter is consulted first, falling back to a lookup by the "Name" tag.
+// An empty ID means no template has been deployed yet.
+func findCurrentJobTemplate(ctx context.Context, clients *awsutils.AWSClients, jobTemplate template.JobTemplateConfig, pmName string, listed *[]types.JobTemplate) (string, error) {
+	if pmName != "" {
+		jobTemplateID, found, err := awsutils.GetSSMParameter(ctx, clients.SSM, pmName)
+		if err != nil {
+
+			return "", fmt.Errorf("failed to read SSM parameter '%s': %w", pmName, err)
+		}
+		if found && jobTemplateID != "" {
+
+			return jobTemplateID, nil
+		}
+	}
+
+	// Only list the account's templates once per plan run.
+	if *listed == nil {
+		templates, err := awsutils.ListJobTemplates(ctx, clients.EMRContainers)
+		if err != nil {
+
+			return "", err
+		}
+		*listed = templates
+	}
+
+	latest := awsutils.FindLatestJobTemplate(*listed, jobTemplate.Name)
+	if latest == nil {
+
+		return "", nil
+	}
+
+	return aws.ToString(latest.Id), nil
+}
+
+// compareDeployedJobTemplate finds the deployed job template of a config and compares it with the
+// input apply sends. An empty ID means no template has been deployed yet, or the one found no
+// longer exists. plan and apply share this rule: a deployed template carrying the fingerprint of
+// the input, or without differences, is unchanged. Templates without the fingerprint tag, such as
+// imported ones, and templates whose fingerprint differs are compared field by field.
+func compareDeployedJobTemplate(ctx context.Context, clients *awsutils.AWSClients, jobTemplate template.JobTemplateConfig, desired *emrcontainers.CreateJobTemplateInput,
+	fingerprint string, listed *[]types.JobTemplate,
+) (string, []awsutils.FieldDiff, error) {
+	// Every parameter of a template is written with the same ID, so the first one is enough.
+	var pmName string
+	if len(jobTemplate.SSMParameters) > 0 {
+		pmName = jobTemplate.SSMParameters[0].Name
+	}
+
+	currentID, err := findCurrentJobTemplate(ctx, clients, jobTemplate, pmName, listed)
+	if err != nil {
+
+		return "", nil, fmt.Errorf("failed to find current job template '%s': %w", jobTemplate.Name, err)
+	}
+	if currentID == "" {
+
+		return "", awsutils.DiffJobTemplate(nil, desired), nil
+	}
+
+	current, err := awsutils.DescribeJobTemplate(ctx, clients.EMRContainers, currentID)
+	if awsutils.IsJobTemplateNotFound(err) {
+
+		return "", awsutils.DiffJobTemplate(nil, desired), nil
+	}
+	if err != nil {
+
+		return "", nil, fmt.Errorf("failed to describe current job template '%s': %w", jobTemplate.Name, err)
+	}
+	if current.Tags[awsutils.FingerprintTag] == fingerprint {
+
+		return currentID, nil, nil
+	}
+
+	return currentID, awsutils.DiffJobTemplate(current, desired), nil
+}
+
+// planJobTemplate computes what an apply would do for a single job template without changing anything.
+func planJobTemplate(ctx context.Context, clients *awsutils.AWSClients, jobTemplate template.JobTemplateConfig, listed *[]types.JobTemplate) (templatePlan, error) {
+	plan := templatePlan{Name: jobTemplate.Name}
+
+	desired, err := awsutils.PrepareJobTemplateInput(jobTemplate, &awsutils.RealParameterConfigurator{}, &awsutils.RealSparkSubmitCommandBuilder{}, &awsutils.RealClientTokenGenerator{})
+	if err != nil {
+
+		return plan, fmt.Errorf("error preparing job template '%s': %w", jobTemplate.Name, err)
+	}
+
+	plan.CurrentID, plan.Diffs, err = compareDeployedJobTemplate(ctx, clients, jobTemplate, desired, awsutils.JobTemplateFingerprint(desired), listed)
+	switch {
+	case err != nil:
+
+		return plan, err
+	case plan.CurrentID == "":
+		plan.Action = planActionCreate
+	case len(plan.Diffs) == 0:
+		plan.Action = planActionUnchanged
+	default:
+		plan.Action = planActionReplace
+	}
+
+	return plan, nil
+}
+
+// writePlan prints a human readable report of the plan.
+func writePlan(w io.Writer, plans []templatePlan) {
+	counts := make(map[string]int)
+	for _, plan := range plans {
+		counts[plan.Action]++
+
+		if plan.CurrentID != "" {
+			fmt.Fprintf(w, "%s: %s (current: %s)\n", plan.Name, plan.Action, plan.CurrentID)
+		} else {
+			fmt.Fprintf(w, "%s: %s\n", plan.Name, plan.Action)
+		}
+
+		for _, diff := range plan.Diffs {
+			switch {
+			case diff.Before == "":
+				fmt.Fprintf(w, "  + %s: %q\n", diff.Field, diff.After)
+			case diff.After == "":
+				fmt.Fprintf(w, "  - %s: %q\n", diff.Field, diff.Before)
+			default:
+				fmt.Fprintf(w, "  ~ %s: %q -> %q\n", diff.Field, diff.Before, diff.After)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "Plan: %d to create, %d to replace, %d unchanged.\n",
+		counts[planActionCreate], counts[planActionReplace], counts[planActionUnchanged])
+}
+
+// runPlan plans every job template and prints the result.
+func runPlan(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, jobTemplates []template.JobTemplateConfig, w io.Writer) error {
+	var listed []types.JobTemplate
+	plans := make([]templatePlan, 0, len(jobTemplates))
+
+	for _, jobTemplate := range jobTemplates {
+		plan, err := planJobTemplate(ctx, clients, jobTemplate, &listed)
+		if err != nil {
+
+			return err
+		}
+		logger.Infof("Planned job template '%s': %s", jobTemplate.Name, plan.Action)
+		plans = append(plans, plan)
+	}
+
+	writePlan(w, plans)
+
+	return nil
+}
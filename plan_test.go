@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+func TestFindCurrentJobTemplate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		values map[string]string
+		pmName string
+		want   string
+	}{
+		{
+			name:   "From SSM Parameter",
+			values: map[string]string{"/emr/etl": "jt-1"},
+			pmName: "/emr/etl",
+			want:   "jt-1",
+		},
+		{
+			name:   "Missing Parameter Falls Back To Name Tag",
+			pmName: "/emr/etl",
+			want:   "jt-2",
+		},
+		{
+			name:   "Empty Parameter Falls Back To Name Tag",
+			values: map[string]string{"/emr/etl": ""},
+			pmName: "/emr/etl",
+			want:   "jt-2",
+		},
+		{
+			name: "Without Parameter",
+			want: "jt-2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fake := newFakeAWS(tt.values)
+			fake.addTemplate("jt-1", "etl", nil, nil)
+			fake.addTemplate("jt-2", "etl", nil, nil)
+			fake.addTemplate("jt-3", "other", nil, nil)
+
+			var listed []types.JobTemplate
+			got, err := findCurrentJobTemplate(context.Background(), fake.clients(), testJobTemplate("etl"), tt.pmName, &listed)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFindCurrentJobTemplate_NotDeployed(t *testing.T) {
+	t.Parallel()
+	fake := newFakeAWS(nil)
+	fake.addTemplate("jt-1", "other", nil, nil)
+
+	var listed []types.JobTemplate
+	got, err := findCurrentJobTemplate(context.Background(), fake.clients(), testJobTemplate("etl"), "/emr/etl", &listed)
+
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	// The listing is kept for the next job template of the plan.
+	assert.Len(t, listed, 1)
+}
+
+func TestRunPlan(t *testing.T) {
+	t.Parallel()
+	changed := testJobTemplate("etl", "/emr/etl")
+	changed.EntryPoint = "s3://bucket/other.py"
+	tests := []struct {
+		name        string
+		deployed    []template.JobTemplateConfig
+		values      map[string]string
+		config      template.JobTemplateConfig
+		wantHeader  string
+		wantDiffs   []string
+		wantSummary string
+	}{
+		{
+			name:        "Create",
+			config:      testJobTemplate("etl", "/emr/etl"),
+			wantHeader:  "etl: create",
+			wantDiffs:   []string{`  + name: "etl"`, `  + jobDriver.entryPoint: "s3://bucket/script.py"`},
+			wantSummary: "Plan: 1 to create, 0 to replace, 0 unchanged.",
+		},
+		{
+			// The parameter points to a template deleted since, apply would create a new one.
+			name:        "Create When Current Was Deleted",
+			values:      map[string]string{"/emr/etl": "jt-gone"},
+			config:      testJobTemplate("etl", "/emr/etl"),
+			wantHeader:  "etl: create",
+			wantDiffs:   []string{`  + name: "etl"`},
+			wantSummary: "Plan: 1 to create, 0 to replace, 0 unchanged.",
+		},
+		{
+			name:        "Unchanged",
+			deployed:    []template.JobTemplateConfig{testJobTemplate("etl", "/emr/etl")},
+			config:      testJobTemplate("etl", "/emr/etl"),
+			wantHeader:  "etl: unchanged (current: jt-1)",
+			wantSummary: "Plan: 0 to create, 0 to replace, 1 unchanged.",
+		},
+		{
+			// Nothing was written to the parameter yet, the template is found by its Name tag.
+			name:        "Unchanged Found By Name Tag",
+			deployed:    []template.JobTemplateConfig{testJobTemplate("etl")},
+			config:      testJobTemplate("etl", "/emr/etl"),
+			wantHeader:  "etl: unchanged (current: jt-1)",
+			wantSummary: "Plan: 0 to create, 0 to replace, 1 unchanged.",
+		},
+		{
+			name:        "Replace",
+			deployed:    []template.JobTemplateConfig{testJobTemplate("etl", "/emr/etl")},
+			config:      changed,
+			wantHeader:  "etl: replace (current: jt-1)",
+			wantDiffs:   []string{`  ~ jobDriver.entryPoint: "s3://bucket/script.py" -> "s3://bucket/other.py"`},
+			wantSummary: "Plan: 0 to create, 1 to replace, 0 unchanged.",
+		},
+		{
+			// The parameter wins over a newer template with the same Name tag.
+			name:        "Replace Current From SSM Parameter",
+			deployed:    []template.JobTemplateConfig{testJobTemplate("etl", "/emr/etl"), changed},
+			values:      map[string]string{"/emr/etl": "jt-1"},
+			config:      changed,
+			wantHeader:  "etl: replace (current: jt-1)",
+			wantDiffs:   []string{`  ~ jobDriver.entryPoint: "s3://bucket/script.py" -> "s3://bucket/other.py"`},
+			wantSummary: "Plan: 0 to create, 1 to replace, 0 unchanged.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fake := newFakeAWS(nil)
+			for _, deployed := range tt.deployed {
+				applyJobTemplate(t, fake, deployed)
+			}
+			for name, value := range tt.values {
+				fake.Values[name] = value
+			}
+			deployedIDs := fake.templateIDs()
+			var out bytes.Buffer
+
+			err := runPlan(context.Background(), testLogger(), fake.clients(), []template.JobTemplateConfig{tt.config}, &out)
+
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			assert.Equal(t, tt.wantHeader, lines[0])
+			assert.Equal(t, tt.wantSummary, lines[len(lines)-1])
+			for _, diff := range tt.wantDiffs {
+				assert.Contains(t, lines, diff)
+			}
+			if tt.wantDiffs == nil {
+				assert.Len(t, lines, 2)
+			}
+			// Planning never changes anything.
+			assert.Equal(t, deployedIDs, fake.templateIDs())
+		})
+	}
+}
+
+func TestRunPlan_Summary(t *testing.T) {
+	t.Parallel()
+	fake := newFakeAWS(nil)
+	applyJobTemplate(t, fake, testJobTemplate("unchanged"))
+	applyJobTemplate(t, fake, testJobTemplate("replaced"))
+	replaced := testJobTemplate("replaced")
+	replaced.ReleaseLabel = "emr-6.5.0-latest"
+	var out bytes.Buffer
+
+	err := runPlan(context.Background(), testLogger(), fake.clients(), []template.JobTemplateConfig{testJobTemplate("unchanged"), replaced, testJobTemplate("created")}, &out)
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Plan: 1 to create, 1 to replace, 1 unchanged.\n")
+}
+
+func TestWritePlan(t *testing.T) {
+	t.Parallel()
+	plans := []templatePlan{
+		{Name: "created", Action: planActionCreate, Diffs: []awsutils.FieldDiff{{Field: "name", After: "created"}}},
+		{Name: "replaced", Action: planActionReplace, CurrentID: "jt-1", Diffs: []awsutils.FieldDiff{
+			{Field: "releaseLabel", Before: "emr-6.4.0-latest", After: "emr-6.5.0-latest"},
+			{Field: "tags.Owner", Before: "team-x"},
+		}},
+		{Name: "unchanged", Action: planActionUnchanged, CurrentID: "jt-2"},
+	}
+	var out bytes.Buffer
+
+	writePlan(&out, plans)
+
+	assert.Equal(t, `created: create
+  + name: "created"
+replaced: replace (current: jt-1)
+  ~ releaseLabel: "emr-6.4.0-latest" -> "emr-6.5.0-latest"
+  - tags.Owner: "team-x"
+unchanged: unchanged (current: jt-2)
+Plan: 1 to create, 1 to replace, 1 unchanged.
+`, out.String())
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// pruneOptions holds the settings of the prune command.
+type pruneOptions struct {
+	Keep   int
+	MinAge time.Duration
+	DryRun bool
+}
+
+// parsePruneFlags parses the prune command line, using the environment configuration as defaults.
+func parsePruneFlags(args []string, cfg Config) (pruneOptions, error) {
+	opts := pruneOptions{}
+
+	flags := flag.NewFlagSet("prune", flag.ContinueOnError)
+	flags.IntVar(&opts.Keep, "keep", cfg.PruneKeep, "number of most recent job templates kept per name")
+	flags.DurationVar(&opts.MinAge, "min-age", cfg.PruneMinAge, "job templates younger than this are never deleted")
+	flags.BoolVar(&opts.DryRun, "dry-run", false, "only list the job templates that would be deleted")
+
+	if err := flags.Parse(args); err != nil {
+
+		return opts, err
+	}
+	if opts.Keep < 1 {
+
+		return opts, fmt.Errorf("keep must be at least 1, got %d", opts.Keep)
+	}
+
+	return opts, nil
+}
+
+// referencedJobTemplateIDs returns the job template IDs currently held by the mapped SSM parameters.
+func referencedJobTemplateIDs(ctx context.Context, clients *awsutils.AWSClients, jobTemplates []template.JobTemplateConfig) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	for _, jobTemplate := range jobTemplates {
+		for _, pmName := range jobTemplate.SSMParameterNames() {
+			jobTemplateID, found, err := awsutils.GetSSMParameter(ctx, clients.SSM, pmName)
+			if err != nil {
+
+				return nil, fmt.Errorf("failed to read SSM parameter '%s': %w", pmName, err)
+			}
+			if found {
+				referenced[jobTemplateID] = true
+			}
+		}
+	}
+
+	return referenced, nil
+}
+
+// runPrune deletes the superseded job templates of every configured template name.
+// Templates with names that are not in the configuration are never touched.
+func runPrune(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, jobTemplates []template.JobTemplateConfig, opts pruneOptions, w io.Writer) error {
+	referenced, err := referencedJobTemplateIDs(ctx, clients, jobTemplates)
+	if err != nil {
+
+		return err
+	}
+
+	templates, err := awsutils.ListJobTemplates(ctx, clients.EMRContainers)
+	if err != nil {
+
+		return err
+	}
+	groups := awsutils.GroupJobTemplatesByName(templates)
+
+	selectOpts := awsutils.PruneOptions{Keep: opts.Keep, MinAge: opts.MinAge, Now: time.Now()}
+	deleted := 0
+	for _, jobTemplate := range jobTemplates {
+		for _, candidate := range awsutils.SelectJobTemplatesToPrune(groups[jobTemplate.Name], referenced, selectOpts) {
+			jobTemplateID := aws.ToString(candidate.Id)
+			createdAt := aws.ToTime(candidate.CreatedAt).Format(time.RFC3339)
+
+			if opts.DryRun {
+				fmt.Fprintf(w, "would delete %s: %s (created %s)\n", jobTemplate.Name, jobTemplateID, createdAt)
+
+				continue
+			}
+
+			if err := awsutils.DeleteJobTemplate(ctx, clients.EMRContainers, jobTemplateID); err != nil {
+
+				return fmt.Errorf("failed to prune job template '%s' (%s): %w", jobTemplate.Name, jobTemplateID, err)
+			}
+			deleted++
+			fmt.Fprintf(w, "deleted %s: %s (created %s)\n", jobTemplate.Name, jobTemplateID, createdAt)
+		}
+	}
+	logger.Infof("Pruned %d superseded job templates", deleted)
+
+	return nil
+}
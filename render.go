@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// writeRender prints the job templates as loaded, with defaults and base templates merged in.
+func writeRender(w io.Writer, jobConfigs *template.Config) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(jobConfigs); err != nil {
+
+		return fmt.Errorf("failed to render job templates: %w", err)
+	}
+
+	return encoder.Close()
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// rollbackTargetPrevious selects the most recent earlier job template.
+const rollbackTargetPrevious = "previous"
+
+// rollbackOptions holds the settings of the rollback command.
+type rollbackOptions struct {
+	Name   string
+	Target string
+	DryRun bool
+}
+
+// parseRollbackFlags parses "rollback <template-name> [--to previous|<id>] [--dry-run]".
+func parseRollbackFlags(args []string) (rollbackOptions, error) {
+	opts := rollbackOptions{}
+
+	// Accept the template name before or after the flags.
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		opts.Name, args = args[0], args[1:]
+	}
+
+	flags := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	flags.StringVar(&opts.Target, "to", rollbackTargetPrevious, "job template ID to roll back to, or \"previous\"")
+	flags.BoolVar(&opts.DryRun, "dry-run", false, "only print the job template that would be restored")
+
+	if err := flags.Parse(args); err != nil {
+
+		return opts, err
+	}
+	if opts.Name == "" && flags.NArg() == 1 {
+		opts.Name = flags.Arg(0)
+	} else if flags.NArg() > 0 {
+
+		return opts, fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+	if opts.Name == "" {
+
+		return opts, errors.New("usage: rollback <template-name> [--to previous|<id>] [--dry-run]")
+	}
+
+	return opts, nil
+}
+
+// findJobTemplateConfig returns the job template config with the given name.
+func findJobTemplateConfig(jobTemplates []template.JobTemplateConfig, name string) (template.JobTemplateConfig, error) {
+	for _, jobTemplate := range jobTemplates {
+		if jobTemplate.Name == name {
+
+			return jobTemplate, nil
+		}
+	}
+
+	return template.JobTemplateConfig{}, fmt.Errorf("job template '%s' not found in configuration", name)
+}
+
+// runRollback repoints the SSM parameters of a job template to an earlier template.
+func runRollback(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, jobTemplates []template.JobTemplateConfig, opts rollbackOptions, w io.Writer) error {
+	jobTemplate, err := findJobTemplateConfig(jobTemplates, opts.Name)
+	if err != nil {
+
+		return err
+	}
+	if len(jobTemplate.SSMParameters) == 0 {
+
+		return fmt.Errorf("job template '%s' has no SSM parameters to roll back", jobTemplate.Name)
+	}
+
+	// Record every parameter first, so a partial update can be restored and a parameter changed
+	// in the meantime aborts the update. The first parameter drives the rollback, as every
+	// parameter of a template holds the same ID.
+	ssmTransaction := awsutils.NewSSMTransaction(clients.SSM)
+	if err := ssmTransaction.Prepare(ctx, jobTemplate.SSMParameters); err != nil {
+
+		return err
+	}
+	pmName := jobTemplate.SSMParameters[0].Name
+	currentID := ssmTransaction.PreviousValues()[pmName]
+
+	targetID, err := resolveRollbackTarget(ctx, logger, clients, jobTemplate.Name, pmName, currentID, opts.Target)
+	if err != nil {
+
+		return err
+	}
+
+	if opts.DryRun {
+		fmt.Fprintf(w, "would roll back %s: %s -> %s\n", jobTemplate.Name, currentID, targetID)
+
+		return nil
+	}
+
+	if err := ssmTransaction.Commit(ctx, targetID); err != nil {
+
+		return rollbackJobTemplate(ctx, logger, clients, jobTemplate.Name, targetID, ssmTransaction, applyOptions{}, err)
+	}
+	for _, param := range jobTemplate.SSMParameters {
+		logger.Infof("Updated SSM parameter '%s' with job template ID: %s", param.Name, targetID)
+	}
+	fmt.Fprintf(w, "rolled back %s: %s -> %s\n", jobTemplate.Name, currentID, targetID)
+
+	// Keep manifests and other outputs in line with the SSM parameters.
+	outputs, err := newOutputSinks(w).forTemplate(jobTemplate)
+	if err != nil {
+
+		return err
+	}
+	if _, err := publishTemplateID(ctx, logger, outputs, jobTemplate.Name, targetID); err != nil {
+
+		return err
+	}
+
+	return nil
+}
+
+// resolveRollbackTarget returns the job template ID to roll back to and checks that it exists and
+// belongs to the job template. For "previous" it takes the most recent earlier job template that
+// still exists, skipping candidates that were deleted since.
+func resolveRollbackTarget(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, name, pmName, currentID, target string) (string, error) {
+	if target != rollbackTargetPrevious {
+		if target == currentID {
+
+			return "", fmt.Errorf("job template '%s' already points to %s", name, target)
+		}
+
+		return target, checkRollbackTarget(ctx, clients, name, target)
+	}
+
+	history, err := awsutils.GetSSMParameterHistory(ctx, clients.SSM, pmName)
+	if err != nil {
+
+		return "", fmt.Errorf("failed to read SSM parameter history '%s': %w", pmName, err)
+	}
+	templates, err := awsutils.ListJobTemplates(ctx, clients.EMRContainers)
+	if err != nil {
+
+		return "", err
+	}
+	groups := awsutils.GroupJobTemplatesByName(templates)
+
+	for _, candidate := range awsutils.RollbackCandidates(currentID, history, groups[name]) {
+		err := checkRollbackTarget(ctx, clients, name, candidate)
+		if awsutils.IsJobTemplateNotFound(err) {
+			logger.Infof("Skipping job template %s of '%s', it no longer exists", candidate, name)
+
+			continue
+		}
+		if err != nil {
+
+			return "", err
+		}
+
+		return candidate, nil
+	}
+
+	return "", fmt.Errorf("no earlier job template found for '%s'", name)
+}
+
+// checkRollbackTarget makes sure the target still exists and belongs to the job template.
+func checkRollbackTarget(ctx context.Context, clients *awsutils.AWSClients, name, targetID string) error {
+	jobTemplate, err := awsutils.DescribeJobTemplate(ctx, clients.EMRContainers, targetID)
+	if err != nil {
+
+		return fmt.Errorf("job template %s cannot be restored: %w", targetID, err)
+	}
+	if targetName := awsutils.JobTemplateName(*jobTemplate); targetName != name {
+
+		return fmt.Errorf("job template %s belongs to '%s', not '%s'", targetID, targetName, name)
+	}
+
+	return nil
+}
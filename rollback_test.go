@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// rollbackAccount returns an account where /emr/a and /emr/b point to jt-3, the third version of "etl".
+func rollbackAccount() *fakeAWS {
+	fake := newFakeAWS(map[string]string{"/emr/a": "jt-3", "/emr/b": "jt-3"})
+	fake.History["/emr/a"] = []string{"jt-1", "jt-2", "jt-3"}
+	for _, id := range []string{"jt-1", "jt-2", "jt-3"} {
+		fake.addTemplate(id, "etl", nil, nil)
+	}
+
+	return fake
+}
+
+func TestRunRollback(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		opts       rollbackOptions
+		modify     func(fake *fakeAWS)
+		wantValue  string
+		wantOutput string
+		wantErr    string
+	}{
+		{
+			name:       "Previous",
+			opts:       rollbackOptions{Name: "etl", Target: rollbackTargetPrevious},
+			wantValue:  "jt-2",
+			wantOutput: "rolled back etl: jt-3 -> jt-2\n",
+		},
+		{
+			name:       "Explicit Target",
+			opts:       rollbackOptions{Name: "etl", Target: "jt-1"},
+			wantValue:  "jt-1",
+			wantOutput: "rolled back etl: jt-3 -> jt-1\n",
+		},
+		{
+			name:       "Dry Run",
+			opts:       rollbackOptions{Name: "etl", Target: rollbackTargetPrevious, DryRun: true},
+			wantValue:  "jt-3",
+			wantOutput: "would roll back etl: jt-3 -> jt-2\n",
+		},
+		{
+			// jt-2 is still in the parameter history but was deleted since.
+			name:       "Skips Deleted Candidate",
+			opts:       rollbackOptions{Name: "etl", Target: rollbackTargetPrevious},
+			modify:     func(fake *fakeAWS) { fake.removeTemplate("jt-2") },
+			wantValue:  "jt-1",
+			wantOutput: "rolled back etl: jt-3 -> jt-1\n",
+		},
+		{
+			// jt-3 was rolled back to jt-2, a second rollback must not return to jt-3.
+			name: "Previous After A Rollback",
+			opts: rollbackOptions{Name: "etl", Target: rollbackTargetPrevious},
+			modify: func(fake *fakeAWS) {
+				fake.Values["/emr/a"], fake.Values["/emr/b"] = "jt-2", "jt-2"
+				fake.History["/emr/a"] = []string{"jt-1", "jt-2", "jt-3", "jt-2"}
+			},
+			wantValue:  "jt-1",
+			wantOutput: "rolled back etl: jt-2 -> jt-1\n",
+		},
+		{
+			name: "No Earlier Template Left",
+			opts: rollbackOptions{Name: "etl", Target: rollbackTargetPrevious},
+			modify: func(fake *fakeAWS) {
+				fake.removeTemplate("jt-1")
+				fake.removeTemplate("jt-2")
+			},
+			wantValue: "jt-3",
+			wantErr:   "no earlier job template found for 'etl'",
+		},
+		{
+			name:      "Deleted Explicit Target",
+			opts:      rollbackOptions{Name: "etl", Target: "jt-2"},
+			modify:    func(fake *fakeAWS) { fake.removeTemplate("jt-2") },
+			wantValue: "jt-3",
+			wantErr:   "job template jt-2 cannot be restored",
+		},
+		{
+			name:      "Target Of Another Job Template",
+			opts:      rollbackOptions{Name: "etl", Target: "jt-9"},
+			modify:    func(fake *fakeAWS) { fake.addTemplate("jt-9", "other", nil, nil) },
+			wantValue: "jt-3",
+			wantErr:   "job template jt-9 belongs to 'other', not 'etl'",
+		},
+		{
+			name:      "Already Current",
+			opts:      rollbackOptions{Name: "etl", Target: "jt-3"},
+			wantValue: "jt-3",
+			wantErr:   "job template 'etl' already points to jt-3",
+		},
+		{
+			name:      "Unknown Job Template",
+			opts:      rollbackOptions{Name: "missing", Target: rollbackTargetPrevious},
+			wantValue: "jt-3",
+			wantErr:   "job template 'missing' not found in configuration",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fake := rollbackAccount()
+			if tt.modify != nil {
+				tt.modify(fake)
+			}
+			var out bytes.Buffer
+
+			err := runRollback(context.Background(), testLogger(), fake.clients(), []template.JobTemplateConfig{testJobTemplate("etl", "/emr/a", "/emr/b")}, tt.opts, &out)
+
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantOutput, out.String())
+			assert.Equal(t, map[string]string{"/emr/a": tt.wantValue, "/emr/b": tt.wantValue}, fake.Values)
+		})
+	}
+}
+
+func TestRunRollback_RestoresSSMParametersOnPartialFailure(t *testing.T) {
+	t.Parallel()
+	fake := rollbackAccount()
+	fake.Values["/emr/c"] = "jt-3"
+	fake.FailPut = map[string]error{"/emr/c": errors.New("access denied")}
+	var out bytes.Buffer
+
+	err := runRollback(context.Background(), testLogger(), fake.clients(), []template.JobTemplateConfig{testJobTemplate("etl", "/emr/a", "/emr/b", "/emr/c")},
+		rollbackOptions{Name: "etl", Target: rollbackTargetPrevious}, &out)
+
+	require.ErrorContains(t, err, "failed to update SSM parameter '/emr/c'")
+	assert.ErrorContains(t, err, "rolled back: restored SSM parameter '/emr/b' to jt-3, restored SSM parameter '/emr/a' to jt-3")
+	assert.NotErrorIs(t, err, errRollbackIncomplete)
+	assert.Empty(t, out.String())
+	assert.Equal(t, map[string]string{"/emr/a": "jt-3", "/emr/b": "jt-3", "/emr/c": "jt-3"}, fake.Values)
+}
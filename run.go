@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// runStartTimeout bounds resolving the job template and starting the job run.
+const runStartTimeout = 30 * time.Second
+
+// runOptions holds the settings of the run command.
+type runOptions struct {
+	Name              string
+	VirtualClusterID  string
+	JobRunName        string
+	Parameters        map[string]string
+	Wait              bool
+	Timeout           time.Duration
+	CancelOnInterrupt bool
+}
+
+// paramFlag collects repeated "--param key=value" flags.
+type paramFlag map[string]string
+
+func (p paramFlag) String() string {
+	pairs := make([]string, 0, len(p))
+	for key, value := range p {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+func (p paramFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+
+		return fmt.Errorf("parameter %q must be key=value", value)
+	}
+	if _, exists := p[key]; exists {
+
+		return fmt.Errorf("parameter %q is set twice", key)
+	}
+	p[key] = val
+
+	return nil
+}
+
+// parseRunFlags parses "run <template-name> [--virtual-cluster <id>] [--name <job-run-name>] [--param key=value]...
+// [--wait [--timeout <duration>] [--cancel-on-interrupt]]".
+func parseRunFlags(args []string, cfg Config) (runOptions, error) {
+	opts := runOptions{Parameters: make(map[string]string)}
+
+	// Accept the template name before or after the flags.
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		opts.Name, args = args[0], args[1:]
+	}
+
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.StringVar(&opts.VirtualClusterID, "virtual-cluster", cfg.VirtualClusterID, "virtual cluster ID to run on")
+	flags.StringVar(&opts.JobRunName, "name", "", "job run name, defaults to the job template name")
+	flags.Var(paramFlag(opts.Parameters), "param", "job template parameter override as key=value, repeatable")
+	flags.BoolVar(&opts.Wait, "wait", false, "wait until the job run finishes and fail unless it completes")
+	flags.DurationVar(&opts.Timeout, "timeout", cfg.JobRunTimeout, "maximum time to wait for the job run")
+	flags.BoolVar(&opts.CancelOnInterrupt, "cancel-on-interrupt", false, "cancel the job run when interrupted or timed out while waiting")
+
+	if err := flags.Parse(args); err != nil {
+
+		return opts, err
+	}
+	if opts.Name == "" && flags.NArg() == 1 {
+		opts.Name = flags.Arg(0)
+	} else if flags.NArg() > 0 {
+
+		return opts, fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+	if opts.Name == "" {
+
+		return opts, errors.New("usage: run <template-name> [--virtual-cluster <id>] [--name <job-run-name>] [--param key=value]... [--wait [--timeout <duration>] [--cancel-on-interrupt]]")
+	}
+	if opts.Wait && opts.Timeout <= 0 {
+
+		return opts, errors.New("--timeout must be positive")
+	}
+	if opts.VirtualClusterID == "" {
+
+		return opts, errors.New("a virtual cluster ID is required, use --virtual-cluster or VIRTUAL_CLUSTER_ID")
+	}
+	if opts.JobRunName == "" {
+		opts.JobRunName = opts.Name
+	}
+
+	return opts, nil
+}
+
+// startJobRun resolves the deployed job template, checks the parameter overrides against its
+// parameter configuration and starts a job run. It returns the job template and job run IDs.
+func startJobRun(ctx context.Context, clients *awsutils.AWSClients, jobTemplate template.JobTemplateConfig, opts runOptions) (string, string, error) {
+	var pmName string
+	if len(jobTemplate.SSMParameters) > 0 {
+		pmName = jobTemplate.SSMParameters[0].Name
+	}
+
+	var listed []types.JobTemplate
+	jobTemplateID, err := findCurrentJobTemplate(ctx, clients, jobTemplate, pmName, &listed)
+	if err != nil {
+
+		return "", "", fmt.Errorf("failed to find deployed job template '%s': %w", jobTemplate.Name, err)
+	}
+	if jobTemplateID == "" {
+
+		return "", "", fmt.Errorf("job template '%s' has not been applied yet", jobTemplate.Name)
+	}
+
+	// The deployed template is the source of truth for the parameter types.
+	deployed, err := awsutils.DescribeJobTemplate(ctx, clients.EMRContainers, jobTemplateID)
+	if err != nil {
+
+		return "", "", fmt.Errorf("failed to describe job template %s: %w", jobTemplateID, err)
+	}
+	var paramConfig map[string]types.TemplateParameterConfiguration
+	if deployed != nil && deployed.JobTemplateData != nil {
+		paramConfig = deployed.JobTemplateData.ParameterConfiguration
+	}
+	if err := awsutils.CheckJobRunParameters(paramConfig, opts.Parameters); err != nil {
+
+		return "", "", fmt.Errorf("job template '%s': %w", jobTemplate.Name, err)
+	}
+
+	jobRunID, err := awsutils.StartJobRun(ctx, clients.EMRContainers, awsutils.JobRunInput{
+		Name:             opts.JobRunName,
+		VirtualClusterID: opts.VirtualClusterID,
+		JobTemplateID:    jobTemplateID,
+		Parameters:       opts.Parameters,
+	})
+	if err != nil {
+
+		return "", "", fmt.Errorf("job template '%s': %w", jobTemplate.Name, err)
+	}
+
+	return jobTemplateID, jobRunID, nil
+}
+
+// runRun starts a job run from a deployed job template and prints the job run ID. With Wait it
+// follows the run until it finishes; ctx is cancelled on interrupt, which optionally cancels the run.
+func runRun(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, jobTemplates []template.JobTemplateConfig, opts runOptions, w io.Writer) error {
+	jobTemplate, err := findJobTemplateConfig(jobTemplates, opts.Name)
+	if err != nil {
+
+		return err
+	}
+
+	startCtx, cancelStart := context.WithTimeout(ctx, runStartTimeout)
+	defer cancelStart()
+
+	jobTemplateID, jobRunID, err := startJobRun(startCtx, clients, jobTemplate, opts)
+	if err != nil {
+
+		return err
+	}
+	logger.Infof("Started job run %s from job template '%s' (%s) on virtual cluster %s", jobRunID, jobTemplate.Name, jobTemplateID, opts.VirtualClusterID)
+	fmt.Fprintf(w, "%s\n", jobRunID)
+
+	if !opts.Wait {
+
+		return nil
+	}
+
+	return waitForJobRun(ctx, logger, clients, opts, jobRunID)
+}
+
+// waitForJobRun waits for a job run to finish within the configured timeout. When the wait stops
+// early, on an interrupt cancelling ctx or at the timeout, the job run is cancelled if
+// CancelOnInterrupt is set and keeps running otherwise.
+func waitForJobRun(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, opts runOptions, jobRunID string) error {
+	waitCtx, cancelWait := context.WithTimeout(ctx, opts.Timeout)
+	defer cancelWait()
+
+	_, err := awsutils.NewJobRunWaiter(clients.EMRContainers, logger).Wait(waitCtx, opts.VirtualClusterID, jobRunID)
+	if err == nil || waitCtx.Err() == nil {
+
+		return err
+	}
+
+	reason := "Timed out"
+	if ctx.Err() != nil {
+		reason = "Interrupted"
+	}
+	if !opts.CancelOnInterrupt {
+		logger.Warnf("%s, job run %s keeps running", reason, jobRunID)
+
+		return err
+	}
+
+	// The expired context cannot be used for the cancellation itself.
+	cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), runStartTimeout)
+	defer cancel()
+	if cancelErr := awsutils.CancelJobRun(cancelCtx, clients.EMRContainers, opts.VirtualClusterID, jobRunID); cancelErr != nil {
+
+		return fmt.Errorf("%w; %w", err, cancelErr)
+	}
+	logger.Warnf("%s, cancelled job run %s", reason, jobRunID)
+
+	return err
+}
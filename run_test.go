@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// runOpts returns the options of "run etl --virtual-cluster vc-1" with the given overrides.
+func runOpts(parameters map[string]string) runOptions {
+	return runOptions{Name: "etl", VirtualClusterID: "vc-1", JobRunName: "etl", Parameters: parameters, Timeout: time.Second}
+}
+
+func TestRunRun(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		deployed   bool
+		values     map[string]string
+		parameters map[string]string
+		wantID     string
+		wantParams map[string]string
+		wantErr    string
+	}{
+		{
+			name:     "Start",
+			deployed: true,
+			wantID:   "jt-1",
+		},
+		{
+			name:       "Parameter Overrides",
+			deployed:   true,
+			parameters: map[string]string{"Executors": "4"},
+			wantID:     "jt-1",
+			wantParams: map[string]string{"Executors": "4"},
+		},
+		{
+			// The SSM parameter wins over the most recent template with the Name tag.
+			name:     "Template From SSM Parameter",
+			deployed: true,
+			values:   map[string]string{"/emr/etl": "jt-0"},
+			wantID:   "jt-0",
+		},
+		{
+			name:       "Undeclared Parameter",
+			deployed:   true,
+			parameters: map[string]string{"Memory": "8G"},
+			wantErr:    "job template 'etl': invalid job run parameters",
+		},
+		{
+			name:       "Invalid Number",
+			deployed:   true,
+			parameters: map[string]string{"Executors": "many"},
+			wantErr:    "job template 'etl': invalid job run parameters",
+		},
+		{
+			name:    "Not Applied",
+			wantErr: "job template 'etl' has not been applied yet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fake := newFakeAWS(nil)
+			if tt.deployed {
+				jobTemplate := testJobTemplate("etl")
+				fake.addTemplate("jt-0", "etl", preparedJobTemplateData(t, jobTemplate), nil)
+				fake.addTemplate("jt-1", "etl", preparedJobTemplateData(t, jobTemplate), nil)
+			}
+			for name, value := range tt.values {
+				fake.Values[name] = value
+			}
+			var out bytes.Buffer
+
+			err := runRun(context.Background(), testLogger(), fake.clients(), []template.JobTemplateConfig{testJobTemplate("etl", "/emr/etl")}, runOpts(tt.parameters), &out)
+
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				assert.Empty(t, fake.Started)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "jr-1\n", out.String())
+			require.Len(t, fake.Started, 1)
+			assert.Equal(t, tt.wantID, aws.ToString(fake.Started[0].JobTemplateId))
+			assert.Equal(t, "vc-1", aws.ToString(fake.Started[0].VirtualClusterId))
+			assert.Equal(t, "etl", aws.ToString(fake.Started[0].Name))
+			assert.Equal(t, tt.wantParams, fake.Started[0].JobTemplateParameters)
+		})
+	}
+}
+
+func TestRunRun_Wait(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		state   types.JobRunState
+		wantErr string
+	}{
+		{
+			name:  "Completed",
+			state: types.JobRunStateCompleted,
+		},
+		{
+			name:    "Failed",
+			state:   types.JobRunStateFailed,
+			wantErr: "FAILED",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fake := newFakeAWS(nil)
+			fake.addTemplate("jt-1", "etl", preparedJobTemplateData(t, testJobTemplate("etl")), nil)
+			fake.JobRunStates = []types.JobRunState{tt.state}
+			opts := runOpts(nil)
+			opts.Wait = true
+			var out bytes.Buffer
+
+			err := runRun(context.Background(), testLogger(), fake.clients(), []template.JobTemplateConfig{testJobTemplate("etl")}, opts, &out)
+
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, "jr-1\n", out.String())
+			assert.Empty(t, fake.Cancelled)
+		})
+	}
+}
+
+func TestWaitForJobRun_StopsEarly(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name              string
+		interrupted       bool
+		cancelOnInterrupt bool
+		wantCancelled     []string
+	}{
+		{
+			name:              "Interrupted",
+			interrupted:       true,
+			cancelOnInterrupt: true,
+			wantCancelled:     []string{"jr-1"},
+		},
+		{
+			name:        "Interrupted Keeps Running",
+			interrupted: true,
+		},
+		{
+			name:              "Timed Out",
+			cancelOnInterrupt: true,
+			wantCancelled:     []string{"jr-1"},
+		},
+		{
+			name: "Timed Out Keeps Running",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			// The job run stays RUNNING, so only the interrupt or the timeout ends the wait.
+			fake := newFakeAWS(nil)
+			opts := runOpts(nil)
+			opts.Timeout = 10 * time.Millisecond
+			opts.CancelOnInterrupt = tt.cancelOnInterrupt
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.interrupted {
+				opts.Timeout = time.Hour
+				cancel()
+			}
+
+			err := waitForJobRun(ctx, testLogger(), fake.clients(), opts, "jr-1")
+
+			if tt.interrupted {
+				require.ErrorIs(t, err, context.Canceled)
+			} else {
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+			}
+			assert.Equal(t, tt.wantCancelled, fake.Cancelled)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// schemaFile is the checked-in copy of the schema, referenced by editors and pre-commit hooks.
+const schemaFile = "schema.json"
+
+// writeSchema prints the JSON Schema of the configuration files.
+func writeSchema(w io.Writer) error {
+	schema, err := template.JSONSchema()
+	if err != nil {
+
+		return fmt.Errorf("failed to generate the schema: %w", err)
+	}
+	_, err = w.Write(schema)
+
+	return err
+}
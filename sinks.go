@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// outputSinks builds the sinks configured in the outputs of the job templates. Sinks writing
+// the same file share their lock, so templates processed in parallel do not overwrite each other.
+type outputSinks struct {
+	stdout *awsutils.WriterSink
+	getenv func(string) string
+
+	mu        sync.Mutex
+	manifests map[string]*awsutils.ManifestSink
+	fileLocks map[string]*sync.Mutex
+}
+
+// newOutputSinks returns a factory printing stdout outputs to w.
+func newOutputSinks(w io.Writer) *outputSinks {
+	return &outputSinks{
+		stdout:    &awsutils.WriterSink{W: w},
+		getenv:    os.Getenv,
+		manifests: make(map[string]*awsutils.ManifestSink),
+		fileLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+// forTemplate returns the sinks of a job template's outputs, in configuration order.
+func (o *outputSinks) forTemplate(jobTemplate template.JobTemplateConfig) ([]awsutils.TemplateIDSink, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	sinks := make([]awsutils.TemplateIDSink, 0, len(jobTemplate.Outputs))
+	for i, output := range jobTemplate.Outputs {
+		if output.Type == template.OutputTypeStdout {
+			sinks = append(sinks, o.stdout)
+
+			continue
+		}
+
+		path := output.Path
+		if output.PathEnv != "" {
+			path = o.getenv(output.PathEnv)
+			if path == "" {
+
+				return nil, fmt.Errorf("output %d of job template '%s': environment variable %s is not set", i+1, jobTemplate.Name, output.PathEnv)
+			}
+		}
+		path = filepath.Clean(path)
+
+		switch output.Type {
+		case template.OutputTypeManifest:
+			sink, err := o.manifest(path, output.Format)
+			if err != nil {
+
+				return nil, fmt.Errorf("output %d of job template '%s': %w", i+1, jobTemplate.Name, err)
+			}
+			sinks = append(sinks, sink)
+		case template.OutputTypeDotenv:
+			if _, ok := o.manifests[path]; ok {
+
+				return nil, fmt.Errorf("output %d of job template '%s': %s is already used by a manifest output", i+1, jobTemplate.Name, path)
+			}
+			key := output.Key
+			if key == "" {
+				key = defaultOutputKey(jobTemplate.Name)
+			}
+			sinks = append(sinks, awsutils.NewDotenvSink(path, key, o.fileLock(path)))
+		default:
+
+			return nil, fmt.Errorf("output %d of job template '%s': unknown type %q", i+1, jobTemplate.Name, output.Type)
+		}
+	}
+
+	return sinks, nil
+}
+
+// manifest returns the single manifest sink of a path, inferring the format from the extension.
+func (o *outputSinks) manifest(path, format string) (*awsutils.ManifestSink, error) {
+	if format == "" {
+		format = awsutils.ManifestFormatJSON
+		if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+			format = awsutils.ManifestFormatYAML
+		}
+	}
+
+	if sink, ok := o.manifests[path]; ok {
+		if sink.Format != format {
+
+			return nil, fmt.Errorf("manifest %s is already written as %s", path, sink.Format)
+		}
+
+		return sink, nil
+	}
+
+	if _, ok := o.fileLocks[path]; ok {
+
+		return nil, fmt.Errorf("%s is already used by a dotenv output", path)
+	}
+
+	sink := &awsutils.ManifestSink{Path: path, Format: format}
+	o.manifests[path] = sink
+
+	return sink, nil
+}
+
+// fileLock returns the lock shared by the dotenv sinks appending to path.
+func (o *outputSinks) fileLock(path string) *sync.Mutex {
+	lock, ok := o.fileLocks[path]
+	if !ok {
+		lock = &sync.Mutex{}
+		o.fileLocks[path] = lock
+	}
+
+	return lock
+}
+
+// defaultOutputKey derives a variable name such as CUSTOM_JOB_TEMPLATE_ID from a job template name.
+func defaultOutputKey(name string) string {
+	key := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if key == "" || (key[0] >= '0' && key[0] <= '9') {
+		key = "_" + key
+	}
+
+	return key + "_TEMPLATE_ID"
+}
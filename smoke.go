@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers"
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// smokeTestDefaultTimeout bounds the smoke test job run when smoke_test.timeout is not set.
+const smokeTestDefaultTimeout = 10 * time.Minute
+
+// smokeTestTimeout returns the time allowed for the smoke test of a job template, zero without one.
+func smokeTestTimeout(jobTemplate template.JobTemplateConfig) time.Duration {
+	switch {
+	case jobTemplate.SmokeTest == nil:
+		return 0
+	case jobTemplate.SmokeTest.Timeout > 0:
+		return jobTemplate.SmokeTest.Timeout
+	default:
+		return smokeTestDefaultTimeout
+	}
+}
+
+// smokeTestRun resolves the job run of a smoke test before the job template is created, so a
+// missing virtual cluster or a bad parameter override fails before anything is changed.
+func smokeTestRun(jobTemplate template.JobTemplateConfig, input *emrcontainers.CreateJobTemplateInput, defaultVirtualClusterID string) (awsutils.JobRunInput, error) {
+	smokeTest := jobTemplate.SmokeTest
+	run := awsutils.JobRunInput{
+		Name:             jobTemplate.Name + "-smoke-test",
+		VirtualClusterID: smokeTest.VirtualClusterID,
+		Parameters:       smokeTest.Parameters,
+	}
+	if run.VirtualClusterID == "" {
+		run.VirtualClusterID = defaultVirtualClusterID
+	}
+	if run.VirtualClusterID == "" {
+
+		return run, fmt.Errorf("smoke test of job template '%s' needs a virtual cluster ID, set smoke_test.virtual_cluster_id or VIRTUAL_CLUSTER_ID", jobTemplate.Name)
+	}
+
+	if err := awsutils.CheckJobRunParameters(input.JobTemplateData.ParameterConfiguration, run.Parameters); err != nil {
+
+		return run, fmt.Errorf("smoke test of job template '%s': %w", jobTemplate.Name, err)
+	}
+
+	return run, nil
+}
+
+// runSmokeTest starts the smoke test job run from the new job template and waits for it to
+// complete. A job run still going when the wait ends is cancelled, so it does not outlive the
+// failed deployment.
+func runSmokeTest(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, jobTemplate template.JobTemplateConfig, run awsutils.JobRunInput) error {
+	jobRunID, err := awsutils.StartJobRun(ctx, clients.EMRContainers, run)
+	if err != nil {
+
+		return err
+	}
+	logger.Infof("Started smoke test job run %s of job template '%s' (%s) on virtual cluster %s", jobRunID, jobTemplate.Name, run.JobTemplateID, run.VirtualClusterID)
+
+	waitCtx, cancel := context.WithTimeout(ctx, smokeTestTimeout(jobTemplate))
+	defer cancel()
+
+	_, err = awsutils.NewJobRunWaiter(clients.EMRContainers, logger).Wait(waitCtx, run.VirtualClusterID, jobRunID)
+	if err == nil || waitCtx.Err() == nil {
+
+		return err
+	}
+
+	// The expired context cannot be used for the cancellation itself.
+	cancelCtx, cancelCancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancelCancel()
+	if cancelErr := awsutils.CancelJobRun(cancelCtx, clients.EMRContainers, run.VirtualClusterID, jobRunID); cancelErr != nil {
+
+		return fmt.Errorf("%w; %w", err, cancelErr)
+	}
+	logger.Warnf("Cancelled smoke test job run %s of job template '%s' (%s)", jobRunID, jobTemplate.Name, run.JobTemplateID)
+
+	return err
+}
+
+// smokeTestJobTemplate runs the smoke test of a new job template and tags the template when it
+// fails. A retry creating the same template again removes the tag once its smoke test passes.
+func smokeTestJobTemplate(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, jobTemplate template.JobTemplateConfig, run awsutils.JobRunInput,
+	created *types.JobTemplate,
+) error {
+	jobTemplateArn := aws.ToString(created.Arn)
+	if err := runSmokeTest(ctx, logger, clients, jobTemplate, run); err != nil {
+		markSmokeTestFailed(ctx, logger, clients, jobTemplate.Name, run.JobTemplateID, jobTemplateArn)
+
+		return fmt.Errorf("smoke test of job template '%s' (%s) failed, SSM parameters left unchanged: %w", jobTemplate.Name, run.JobTemplateID, err)
+	}
+	logger.Infof("Smoke test of job template '%s' (%s) completed", jobTemplate.Name, run.JobTemplateID)
+
+	if awsutils.IsFailedSmokeTest(*created) {
+		if err := awsutils.UntagJobTemplate(ctx, clients.EMRContainers, jobTemplateArn, awsutils.StatusTag); err != nil {
+
+			return fmt.Errorf("failed to clear %s tag of job template '%s' (%s): %w", awsutils.StatusTag, jobTemplate.Name, run.JobTemplateID, err)
+		}
+		logger.Infof("Removed %s=%s from job template '%s' (%s)", awsutils.StatusTag, awsutils.StatusFailedSmoke, jobTemplate.Name, run.JobTemplateID)
+	}
+
+	return nil
+}
+
+// markSmokeTestFailed tags a job template whose smoke test failed. Failing to tag is only logged,
+// the smoke test failure is what gets reported.
+func markSmokeTestFailed(ctx context.Context, logger *logrus.Logger, clients *awsutils.AWSClients, name, jobTemplateID, jobTemplateArn string) {
+	// The template context may have expired while waiting for the smoke test.
+	tagCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	tags := map[string]string{awsutils.StatusTag: awsutils.StatusFailedSmoke}
+	if err := awsutils.TagJobTemplate(tagCtx, clients.EMRContainers, jobTemplateArn, tags); err != nil {
+		logger.Warnf("Failed to mark job template '%s' (%s) as %s: %v", name, jobTemplateID, awsutils.StatusFailedSmoke, err)
+
+		return
+	}
+	logger.Warnf("Marked job template '%s' (%s) with %s=%s", name, jobTemplateID, awsutils.StatusTag, awsutils.StatusFailedSmoke)
+}
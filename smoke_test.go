@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GoGstickGo/emr-containers-template/awsutils"
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// smokeTestedJobTemplate returns a job template with a smoke test publishing to /emr/a.
+func smokeTestedJobTemplate(timeout time.Duration) template.JobTemplateConfig {
+	jobTemplate := testJobTemplate("etl", "/emr/a")
+	jobTemplate.SmokeTest = &template.SmokeTestConfig{VirtualClusterID: "vc-1", Timeout: timeout}
+
+	return jobTemplate
+}
+
+func TestProcessJobTemplate_SmokeTest(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		states        []types.JobRunState
+		wantErr       string
+		wantValue     string
+		wantTags      map[string]string
+		wantCancelled []string
+	}{
+		{
+			name:      "Completed",
+			states:    []types.JobRunState{types.JobRunStateCompleted},
+			wantValue: "jt-1",
+		},
+		{
+			name:      "SSM Unchanged On Smoke Failure",
+			states:    []types.JobRunState{types.JobRunStateFailed},
+			wantErr:   "smoke test of job template 'etl' (jt-1) failed, SSM parameters left unchanged: job run jr-1 FAILED",
+			wantValue: "jt-old",
+			wantTags:  map[string]string{awsutils.StatusTag: awsutils.StatusFailedSmoke},
+		},
+		{
+			name:          "Cancelled On Timeout",
+			states:        []types.JobRunState{types.JobRunStateRunning},
+			wantErr:       "stopped waiting for job run jr-1 in state RUNNING: context deadline exceeded",
+			wantValue:     "jt-old",
+			wantTags:      map[string]string{awsutils.StatusTag: awsutils.StatusFailedSmoke},
+			wantCancelled: []string{"jr-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fake := newFakeAWS(map[string]string{"/emr/a": "jt-old"})
+			fake.JobRunStates = tt.states
+
+			err := processJobTemplate(context.Background(), testLogger(), fake.clients(), smokeTestedJobTemplate(10*time.Millisecond),
+				&awsutils.RealClientTokenGenerator{}, applyOptions{})
+
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, map[string]string{"/emr/a": tt.wantValue}, fake.Values)
+			require.Len(t, fake.Started, 1)
+			assert.Equal(t, "jt-1", *fake.Started[0].JobTemplateId)
+			assert.Equal(t, tt.wantCancelled, fake.Cancelled)
+			assert.Equal(t, tt.wantTags[awsutils.StatusTag], fake.template("jt-1").Tags[awsutils.StatusTag])
+		})
+	}
+}
+
+func TestProcessJobTemplate_SmokeTestRetry(t *testing.T) {
+	t.Parallel()
+	fake := newFakeAWS(map[string]string{"/emr/a": "jt-old"})
+	clients := fake.clients()
+	fake.JobRunStates = []types.JobRunState{types.JobRunStateFailed}
+
+	err := processJobTemplate(context.Background(), testLogger(), clients, smokeTestedJobTemplate(time.Minute), &awsutils.RealClientTokenGenerator{}, applyOptions{})
+	require.ErrorContains(t, err, "SSM parameters left unchanged")
+	assert.True(t, awsutils.IsFailedSmokeTest(*fake.template("jt-1")))
+
+	// The retry gets the same template back through the client token and clears the tag once it passes.
+	fake.JobRunStates = []types.JobRunState{types.JobRunStateCompleted}
+	err = processJobTemplate(context.Background(), testLogger(), clients, smokeTestedJobTemplate(time.Minute), &awsutils.RealClientTokenGenerator{}, applyOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jt-1"}, fake.templateIDs())
+	assert.False(t, awsutils.IsFailedSmokeTest(*fake.template("jt-1")))
+	assert.Equal(t, map[string]string{"/emr/a": "jt-1"}, fake.Values)
+}
+
+func TestFindCurrentJobTemplate_SkipsFailedSmokeTest(t *testing.T) {
+	t.Parallel()
+	fake := newFakeAWS(nil)
+	fake.addTemplate("jt-good", "etl", nil, nil)
+	fake.addTemplate("jt-failed", "etl", nil, map[string]string{awsutils.StatusTag: awsutils.StatusFailedSmoke})
+
+	var listed []types.JobTemplate
+	jobTemplateID, err := findCurrentJobTemplate(context.Background(), fake.clients(), testJobTemplate("etl"), "", &listed)
+
+	require.NoError(t, err)
+	assert.Equal(t, "jt-good", jobTemplateID)
+}
@@ -0,0 +1,181 @@
+package template
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvironmentTag is the tag set on every job template to the selected environment.
+const EnvironmentTag = "Environment"
+
+// environmentsKey is the key of the inline environment overlays.
+const environmentsKey = "environments"
+
+// environmentNamePattern keeps environment names usable in overlay file names.
+var environmentNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// environmentOverlay is the layout of an environment overlay, inline or in its own file.
+// Job templates are matched by name and base templates by key.
+type environmentOverlay struct {
+	Defaults      JobTemplateConfig            `yaml:"defaults"`
+	BaseTemplates map[string]JobTemplateConfig `yaml:"base_templates"`
+	JobTemplates  []JobTemplateConfig          `yaml:"job_templates"`
+}
+
+type loadOptions struct {
+	environment string
+	lookupEnv   func(string) (string, bool)
+}
+
+// LoadOption configures LoadConfig.
+type LoadOption func(*loadOptions)
+
+// WithEnvironment applies the overlay of the named environment, from the environments section
+// and from the overlay file next to the configuration, e.g. templates.prod.yaml for templates.yaml.
+// An empty name loads the configuration as is.
+func WithEnvironment(name string) LoadOption {
+	return func(o *loadOptions) {
+		o.environment = name
+	}
+}
+
+// WithLookupEnv replaces os.LookupEnv when resolving ${env:VAR} references.
+func WithLookupEnv(lookupEnv func(string) (string, bool)) LoadOption {
+	return func(o *loadOptions) {
+		o.lookupEnv = lookupEnv
+	}
+}
+
+// OverlayFilePath returns the path of the overlay file of an environment.
+func OverlayFilePath(filePath, environment string) string {
+	ext := filepath.Ext(filePath)
+
+	return strings.TrimSuffix(filePath, ext) + "." + environment + ext
+}
+
+// applyEnvironment patches the document with the inline overlay and then the overlay file of the
+// environment, and reports whether either exists. The environments section is removed whether or
+// not an environment is selected.
+func applyEnvironment(file string, document *yaml.Node, options loadOptions, configErrors *ConfigErrors) (bool, error) {
+	environment := options.environment
+	root := document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	environments := mappingValue(root, environmentsKey)
+	removeMappingKey(root, environmentsKey)
+	if environment == "" || root.Kind != yaml.MappingNode {
+		return false, nil
+	}
+
+	found := false
+	if overlay := mappingValue(environments, environment); overlay != nil {
+		found = true
+		applyOverlay(file, root, resolveAlias(overlay), environment, configErrors)
+	}
+
+	overlayFile := OverlayFilePath(file, environment)
+	overlayDocument, err := readDocument(overlayFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return found, err
+	default:
+		found = true
+		renameDeprecatedKeys(overlayFile, overlayDocument, configErrors)
+		checkKnownFields(overlayFile, overlayDocument, reflect.TypeOf(environmentOverlay{}), configErrors)
+		interpolator := &interpolator{file: overlayFile, lookupEnv: options.lookupEnv, configErrors: configErrors}
+		interpolator.interpolateDocument(overlayDocument, "")
+		if overlayDocument.Kind == yaml.DocumentNode && len(overlayDocument.Content) > 0 {
+			applyOverlay(overlayFile, root, overlayDocument.Content[0], environment, configErrors)
+		}
+	}
+
+	return found, nil
+}
+
+// applyOverlay merges an overlay into the defaults, base templates and job templates of the
+// document root, with the same rules as inheritance. Overlay entries must patch existing ones.
+func applyOverlay(file string, root, overlay *yaml.Node, environment string, configErrors *ConfigErrors) {
+	if overlay.Kind != yaml.MappingNode {
+		return
+	}
+
+	if defaults := mappingValue(overlay, defaultsKey); defaults != nil {
+		if index := mappingIndex(root, defaultsKey); index >= 0 {
+			root.Content[index+1] = mergeNodes(root.Content[index+1], defaults)
+		} else {
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: defaultsKey}, copyNode(defaults))
+		}
+	}
+
+	if baseTemplates := mappingValue(overlay, baseTemplatesKey); baseTemplates != nil && baseTemplates.Kind == yaml.MappingNode {
+		target := mappingValue(root, baseTemplatesKey)
+		for i := 0; i+1 < len(baseTemplates.Content); i += 2 {
+			key := baseTemplates.Content[i]
+			index := -1
+			if target != nil && target.Kind == yaml.MappingNode {
+				index = mappingIndex(target, key.Value)
+			}
+			if index < 0 {
+				*configErrors = append(*configErrors, newConfigError(file, key, "base template %q of environment %q does not exist", key.Value, environment))
+
+				continue
+			}
+			target.Content[index+1] = mergeNodes(target.Content[index+1], baseTemplates.Content[i+1])
+		}
+	}
+
+	if jobTemplates := mappingValue(overlay, "job_templates"); jobTemplates != nil && jobTemplates.Kind == yaml.SequenceNode {
+		target := mappingValue(root, "job_templates")
+		for _, patch := range jobTemplates.Content {
+			name := mappingValue(patch, "name")
+			if name == nil {
+				*configErrors = append(*configErrors, newConfigError(file, patch, "job templates of environment %q must have a name", environment))
+
+				continue
+			}
+			index := jobTemplateIndex(target, name.Value)
+			if index < 0 {
+				*configErrors = append(*configErrors, newConfigError(file, name, "job template %q of environment %q does not exist", name.Value, environment))
+
+				continue
+			}
+			target.Content[index] = mergeNodes(target.Content[index], patch)
+		}
+	}
+}
+
+// jobTemplateIndex returns the index of the job template with the given name in a sequence node, or -1.
+func jobTemplateIndex(jobTemplates *yaml.Node, name string) int {
+	if jobTemplates == nil || jobTemplates.Kind != yaml.SequenceNode {
+		return -1
+	}
+	for i, jobTemplate := range jobTemplates.Content {
+		if node := mappingValue(resolveAlias(jobTemplate), "name"); node != nil && node.Value == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// setEnvironmentTag tags every job template with the environment, replacing a different value.
+func setEnvironmentTag(config *Config, environment string) {
+	for i := range config.JobTemplates {
+		jobTemplate := &config.JobTemplates[i]
+		if current, ok := jobTemplate.Tags[EnvironmentTag]; ok && current != environment {
+			logger.Warnf("job template %q: tag %s=%q replaced by environment %q", jobTemplate.Name, EnvironmentTag, current, environment)
+		}
+		if jobTemplate.Tags == nil {
+			jobTemplate.Tags = make(map[string]string)
+		}
+		jobTemplate.Tags[EnvironmentTag] = environment
+	}
+}
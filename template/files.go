@@ -0,0 +1,167 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configFileExtensions lists the extensions of the files loaded from a directory.
+var configFileExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true, ".toml": true}
+
+// LoadConfig loads the job templates of a configuration file, of every YAML, JSON or TOML file in
+// a directory, or of every file matching a glob pattern such as templates/*.yaml. Defaults, base
+// templates and environment overlays apply within their own file. Job template names must be
+// unique across files.
+func LoadConfig(path string, opts ...LoadOption) (*Config, error) {
+	options := loadOptions{lookupEnv: os.LookupEnv}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.environment != "" && !environmentNamePattern.MatchString(options.environment) {
+		return nil, fmt.Errorf("invalid environment name %q", options.environment)
+	}
+
+	files, err := configFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	var configErrors ConfigErrors
+	found := false
+	for _, file := range files {
+		fileConfig, fileFound, err := loadFile(file, options)
+		if fileErrors, ok := err.(ConfigErrors); ok {
+			// Report the problems of every file together.
+			configErrors = append(configErrors, fileErrors...)
+
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = found || fileFound
+		config.JobTemplates = append(config.JobTemplates, fileConfig.JobTemplates...)
+	}
+	checkUniqueNames(config.JobTemplates, &configErrors)
+	if len(configErrors) > 0 {
+		return nil, configErrors
+	}
+
+	if options.environment != "" {
+		if !found {
+			if len(files) == 1 {
+				return nil, fmt.Errorf("unknown environment %q: not in %s and %s does not exist",
+					options.environment, environmentsKey, OverlayFilePath(files[0], options.environment))
+			}
+
+			return nil, fmt.Errorf("unknown environment %q: no configuration file in %s has an overlay for it", options.environment, path)
+		}
+		config.Environment = options.environment
+		setEnvironmentTag(&config, options.environment)
+	}
+
+	return &config, nil
+}
+
+// configFiles returns the configuration files named by a file path, a directory or a glob pattern,
+// in lexical order. Overlay files such as templates.prod.yaml next to templates.yaml are left out.
+func configFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading directory %s: %w", path, err)
+		}
+
+		var files []string
+		for _, entry := range entries {
+			if !entry.IsDir() && configFileExtensions[filepath.Ext(entry.Name())] {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+
+		return nonEmpty(path, withoutOverlayFiles(files))
+	case err == nil || !strings.ContainsAny(path, "*?["):
+		// A missing file is reported when it is read.
+		return []string{path}, nil
+	default:
+		files, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration pattern %s: %w", path, err)
+		}
+
+		return nonEmpty(path, withoutOverlayFiles(files))
+	}
+}
+
+// nonEmpty fails when no configuration file was found for path.
+func nonEmpty(path string, files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no configuration files found in %s", path)
+	}
+
+	return files, nil
+}
+
+// withoutOverlayFiles drops the files that are the environment overlay of another file of the list.
+func withoutOverlayFiles(files []string) []string {
+	listed := make(map[string]bool, len(files))
+	for _, file := range files {
+		listed[file] = true
+	}
+
+	kept := make([]string, 0, len(files))
+	for _, file := range files {
+		ext := filepath.Ext(file)
+		base, environment, isOverlay := cutLast(strings.TrimSuffix(file, ext), ".")
+		if isOverlay && environmentNamePattern.MatchString(environment) && listed[base+ext] {
+			continue
+		}
+		kept = append(kept, file)
+	}
+
+	return kept
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
+
+// checkUniqueNames records every job template whose name is already used, with both locations.
+func checkUniqueNames(jobTemplates []JobTemplateConfig, configErrors *ConfigErrors) {
+	first := make(map[string]JobTemplateConfig, len(jobTemplates))
+	for _, jobTemplate := range jobTemplates {
+		if jobTemplate.Name == "" {
+			continue
+		}
+		if previous, ok := first[jobTemplate.Name]; ok {
+			*configErrors = append(*configErrors, ConfigError{
+				File:    jobTemplate.SourceFile,
+				Line:    jobTemplate.SourceLine,
+				Message: fmt.Sprintf("duplicate job template name %q, also defined at %s", jobTemplate.Name, sourceLocation(previous)),
+			})
+
+			continue
+		}
+		first[jobTemplate.Name] = jobTemplate
+	}
+}
+
+// sourceLocation formats the file and line of a job template, the line being unknown for TOML files.
+func sourceLocation(jobTemplate JobTemplateConfig) string {
+	if jobTemplate.SourceLine == 0 {
+		return jobTemplate.SourceFile
+	}
+
+	return fmt.Sprintf("%s:%d", jobTemplate.SourceFile, jobTemplate.SourceLine)
+}
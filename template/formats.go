@@ -0,0 +1,142 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// jsonDocument decodes a JSON file into a YAML document. The file is read with encoding/json, so
+// every escape JSON allows is understood, and the line and column of every value are kept for the
+// error messages. As with encoding/json, a repeated key replaces the earlier value.
+func jsonDocument(filePath string, data []byte) (*yaml.Node, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	d := &jsonNodeDecoder{data: data, decoder: decoder}
+
+	content, err := d.value()
+	if err == nil {
+		if _, err = decoder.Token(); errors.Is(err, io.EOF) {
+			err = nil
+		} else if err == nil {
+			err = errors.New("invalid character after top-level value")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal func returned error: %s: %w", filePath, err)
+	}
+
+	return &yaml.Node{Kind: yaml.DocumentNode, Line: content.Line, Column: content.Column, Content: []*yaml.Node{content}}, nil
+}
+
+// jsonNodeDecoder builds YAML nodes from the tokens of a JSON decoder.
+type jsonNodeDecoder struct {
+	data    []byte
+	decoder *json.Decoder
+}
+
+// value decodes the next JSON value into a node.
+func (d *jsonNodeDecoder) value() (*yaml.Node, error) {
+	// The token starts after the whitespace and separators that follow the previous one.
+	start := int(d.decoder.InputOffset())
+	token, err := d.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	for start < len(d.data) && strings.IndexByte(" \t\r\n,:", d.data[start]) >= 0 {
+		start++
+	}
+	lineStart := bytes.LastIndexByte(d.data[:start], '\n') + 1
+	node := &yaml.Node{
+		Kind:   yaml.ScalarNode,
+		Line:   1 + bytes.Count(d.data[:start], []byte("\n")),
+		Column: 1 + utf8.RuneCount(d.data[lineStart:start]),
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '[' {
+			return d.sequence(node)
+		}
+
+		return d.mapping(node)
+	case string:
+		node.Tag, node.Value = "!!str", token
+	case json.Number:
+		node.Tag, node.Value = "!!int", token.String()
+		if strings.ContainsAny(token.String(), ".eE") {
+			node.Tag = "!!float"
+		}
+	case bool:
+		node.Tag, node.Value = "!!bool", strconv.FormatBool(token)
+	default:
+		node.Tag, node.Value = "!!null", "null"
+	}
+
+	return node, nil
+}
+
+// sequence decodes the items of a JSON array up to its closing bracket.
+func (d *jsonNodeDecoder) sequence(node *yaml.Node) (*yaml.Node, error) {
+	node.Kind, node.Tag = yaml.SequenceNode, "!!seq"
+	for d.decoder.More() {
+		item, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, item)
+	}
+	if _, err := d.decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// mapping decodes the members of a JSON object up to its closing brace.
+func (d *jsonNodeDecoder) mapping(node *yaml.Node) (*yaml.Node, error) {
+	node.Kind, node.Tag = yaml.MappingNode, "!!map"
+	for d.decoder.More() {
+		key, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		if i := mappingIndex(node, key.Value); i >= 0 {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+		}
+		node.Content = append(node.Content, key, value)
+	}
+	if _, err := d.decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// tomlDocument decodes a TOML file into a YAML document. TOML keeps no positions, so problems
+// in TOML files are reported without a line.
+func tomlDocument(filePath string, data []byte) (*yaml.Node, error) {
+	var value map[string]any
+	if err := toml.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("unmarshal func returned error: %s: %w", filePath, err)
+	}
+
+	var content yaml.Node
+	if err := content.Encode(value); err != nil {
+		return nil, fmt.Errorf("unmarshal func returned error: %s: %w", filePath, err)
+	}
+
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&content}}, nil
+}
@@ -0,0 +1,89 @@
+package template_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoGstickGo/emr-containers-template/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeJSONConfig writes a JSON configuration file with a single job template and returns its path.
+func writeJSONConfig(t *testing.T, jobTemplate string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "templates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"job_templates": [`+jobTemplate+`]}`), 0o600))
+
+	return path
+}
+
+func TestLoadConfig_JSONEscapes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		jobTemplate string
+		want        template.JobTemplateConfig
+	}{
+		{
+			name:        "Escaped Slashes",
+			jobTemplate: `{"name": "etl", "entry_point":"s3:\/\/x\/y"}`,
+			want:        template.JobTemplateConfig{Name: "etl", EntryPoint: "s3://x/y"},
+		},
+		{
+			// Python's json.dumps writes characters outside the BMP as surrogate pairs.
+			name:        "Surrogate Pairs",
+			jobTemplate: `{"name": "etl", "tags": {"Mood": "\ud83d\ude00"}}`,
+			want:        template.JobTemplateConfig{Name: "etl", Tags: map[string]string{"Mood": "😀"}},
+		},
+		{
+			name:        "Non-ASCII Escapes",
+			jobTemplate: `{"name": "etl", "tags": {"Owner": "Ren\u00e9e \u00fc\u4e2d", "Path": "a\\b\t\"c\""}}`,
+			want:        template.JobTemplateConfig{Name: "etl", Tags: map[string]string{"Owner": "Renée ü中", "Path": "a\\b\t\"c\""}},
+		},
+		{
+			// As with encoding/json, the last value of a repeated key wins.
+			name:        "Duplicate Keys",
+			jobTemplate: `{"name": "etl", "release_label": "emr-6.4.0-latest", "release_label": "emr-6.5.0-latest"}`,
+			want:        template.JobTemplateConfig{Name: "etl", ReleaseLabel: "emr-6.5.0-latest"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := writeJSONConfig(t, tt.jobTemplate)
+
+			got, err := template.LoadConfig(path)
+
+			require.NoError(t, err)
+			require.Len(t, got.JobTemplates, 1)
+			tt.want.SourceFile, tt.want.SourceLine = path, 1
+			assert.Equal(t, tt.want, got.JobTemplates[0])
+		})
+	}
+}
+
+func TestLoadConfig_JSONPositions(t *testing.T) {
+	t.Parallel()
+	path := writeJSONConfig(t, "\n\t{\"name\": \"étl\",\n\t \"release_lable\": \"emr-6.4.0-latest\"}")
+
+	_, err := template.LoadConfig(path)
+
+	// Tabs count as one column, like in YAML files.
+	assert.Equal(t, template.ConfigErrors{
+		{File: path, Line: 3, Column: 3, Message: `unknown field "release_lable" in JobTemplateConfig`},
+	}, err)
+}
+
+func TestLoadConfig_InvalidJSON(t *testing.T) {
+	t.Parallel()
+	for _, data := range []string{`{"job_templates": [}`, `{"job_templates": []} []`, `{"job_templates": "\x"}`} {
+		path := filepath.Join(t.TempDir(), "templates.json")
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+		_, err := template.LoadConfig(path)
+
+		require.ErrorContains(t, err, "unmarshal func returned error: "+path, data)
+	}
+}
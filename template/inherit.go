@@ -0,0 +1,261 @@
+package template
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Keys of the blocks job templates inherit from; they are resolved before the job templates are decoded.
+const (
+	defaultsKey      = "defaults"
+	baseTemplatesKey = "base_templates"
+	extendsKey       = "extends"
+)
+
+// List merge directives. An inherited list is replaced unless the overriding list is tagged !append.
+const (
+	appendTag  = "!append"
+	replaceTag = "!replace"
+)
+
+// configFile is the layout of a configuration file before inheritance is resolved.
+type configFile struct {
+	Defaults      JobTemplateConfig             `yaml:"defaults"`
+	BaseTemplates map[string]JobTemplateConfig  `yaml:"base_templates"`
+	JobTemplates  []JobTemplateConfig           `yaml:"job_templates"`
+	Environments  map[string]environmentOverlay `yaml:"environments"`
+}
+
+// inheritanceResolver resolves base templates once, following their extends chains.
+type inheritanceResolver struct {
+	file          string
+	baseTemplates *yaml.Node
+	resolved      map[string]*yaml.Node
+	chain         []string
+	configErrors  *ConfigErrors
+}
+
+// resolveInheritance merges the defaults and the base template named by extends into every
+// job template and removes the defaults and base_templates blocks from the document.
+// Scalars override, mappings are merged key by key and lists replace the inherited list
+// unless tagged !append.
+func resolveInheritance(file string, document *yaml.Node, configErrors *ConfigErrors) {
+	root := document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+
+	checkMergeTags(file, root, configErrors)
+
+	defaults := mappingValue(root, defaultsKey)
+	for _, key := range []string{"name", extendsKey} {
+		if node := mappingKey(defaults, key); node != nil {
+			*configErrors = append(*configErrors, newConfigError(file, node, "%s cannot be set in %s", key, defaultsKey))
+		}
+	}
+
+	resolver := &inheritanceResolver{
+		file:          file,
+		baseTemplates: mappingValue(root, baseTemplatesKey),
+		resolved:      make(map[string]*yaml.Node),
+		configErrors:  configErrors,
+	}
+	if resolver.baseTemplates != nil && resolver.baseTemplates.Kind == yaml.MappingNode {
+		// Resolve every base template, so problems in unused ones are reported too.
+		for i := 0; i+1 < len(resolver.baseTemplates.Content); i += 2 {
+			key := resolver.baseTemplates.Content[i]
+			if node := mappingKey(resolver.baseTemplates.Content[i+1], "name"); node != nil {
+				*configErrors = append(*configErrors, newConfigError(file, node, "name cannot be set in base template %q", key.Value))
+			}
+			resolver.base(key)
+		}
+	}
+
+	if jobTemplates := mappingValue(root, "job_templates"); jobTemplates != nil && jobTemplates.Kind == yaml.SequenceNode {
+		for i, jobTemplate := range jobTemplates.Content {
+			inherited := defaults
+			if extends := mappingValue(jobTemplate, extendsKey); extends != nil {
+				if base := resolver.base(extends); base != nil {
+					inherited = mergeNodes(defaults, base)
+				}
+			}
+
+			resolved := mergeNodes(inherited, jobTemplate)
+			removeMappingKey(resolved, extendsKey)
+			clearMergeTags(resolved)
+			jobTemplates.Content[i] = resolved
+		}
+	}
+
+	removeMappingKey(root, defaultsKey)
+	removeMappingKey(root, baseTemplatesKey)
+}
+
+// ownParameterKeys returns the parameter_configuration keys every job template entry of a document
+// declares itself, before the defaults and base templates are merged in.
+func ownParameterKeys(document *yaml.Node) []map[string]bool {
+	root := document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	jobTemplates := mappingValue(root, "job_templates")
+	if jobTemplates == nil || jobTemplates.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	keys := make([]map[string]bool, 0, len(jobTemplates.Content))
+	for _, jobTemplate := range jobTemplates.Content {
+		own := make(map[string]bool)
+		if params := mappingValue(jobTemplate, "parameter_configuration"); params != nil && params.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(params.Content); i += 2 {
+				own[params.Content[i].Value] = true
+			}
+		}
+		keys = append(keys, own)
+	}
+
+	return keys
+}
+
+// inheritedParameters returns the sorted keys of a resolved parameter configuration that the entry
+// did not declare itself, nil without any.
+func inheritedParameters(paramConfig map[string]TemplateParameterConfiguration, own map[string]bool) []string {
+	var inherited []string
+	for _, name := range sortedKeys(paramConfig) {
+		if !own[name] {
+			inherited = append(inherited, name)
+		}
+	}
+
+	return inherited
+}
+
+// base returns the base template named by the node merged with the base templates it extends,
+// or nil when it does not exist or extends itself.
+func (r *inheritanceResolver) base(nameNode *yaml.Node) *yaml.Node {
+	name := nameNode.Value
+	if resolved, ok := r.resolved[name]; ok {
+		return resolved
+	}
+
+	node := mappingValue(r.baseTemplates, name)
+	if node == nil {
+		*r.configErrors = append(*r.configErrors, newConfigError(r.file, nameNode, "unknown base template %q", name))
+
+		return nil
+	}
+
+	if start := slices.Index(r.chain, name); start >= 0 {
+		cycle := append(slices.Clone(r.chain[start:]), name)
+		*r.configErrors = append(*r.configErrors, newConfigError(r.file, nameNode, "base templates extend each other: %s", strings.Join(cycle, " -> ")))
+
+		return nil
+	}
+
+	r.chain = append(r.chain, name)
+	var parent *yaml.Node
+	if extends := mappingValue(node, extendsKey); extends != nil {
+		parent = r.base(extends)
+	}
+	r.chain = r.chain[:len(r.chain)-1]
+
+	resolved := mergeNodes(parent, node)
+	removeMappingKey(resolved, extendsKey)
+	r.resolved[name] = resolved
+
+	return resolved
+}
+
+// mergeNodes returns a copy of base with override merged into it. Either node may be nil.
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	base, override = resolveAlias(base), resolveAlias(override)
+	switch {
+	case override == nil:
+		return copyNode(base)
+	case base == nil:
+		return copyNode(override)
+	case base.Kind == yaml.MappingNode && override.Kind == yaml.MappingNode:
+		merged := copyNode(base)
+		for i := 0; i+1 < len(override.Content); i += 2 {
+			key, value := override.Content[i], override.Content[i+1]
+			if index := mappingIndex(merged, key.Value); index >= 0 {
+				merged.Content[index+1] = mergeNodes(merged.Content[index+1], value)
+			} else {
+				merged.Content = append(merged.Content, copyNode(key), copyNode(value))
+			}
+		}
+
+		return merged
+	case base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode && override.Tag == appendTag:
+		// The merged list keeps the directive of base: it still appends to what base inherits
+		// when base was tagged !append, and replaces it otherwise.
+		merged := copyNode(base)
+		merged.Content = append(merged.Content, copyNode(override).Content...)
+
+		return merged
+	default:
+		return copyNode(override)
+	}
+}
+
+// copyNode returns a deep copy of a node with aliases replaced by the nodes they refer to.
+func copyNode(node *yaml.Node) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil {
+		return nil
+	}
+
+	copied := *node
+	copied.Anchor = ""
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = copyNode(child)
+	}
+
+	return &copied
+}
+
+// resolveAlias returns the node an alias refers to, or the node itself.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	return node
+}
+
+// mappingIndex returns the index of the key node with the given name in a mapping node, or -1.
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// checkMergeTags records every merge directive that is not used on a list.
+func checkMergeTags(file string, node *yaml.Node, configErrors *ConfigErrors) {
+	if (node.Tag == appendTag || node.Tag == replaceTag) && node.Kind != yaml.SequenceNode {
+		*configErrors = append(*configErrors, newConfigError(file, node, "%s can only be used on a list", node.Tag))
+	}
+	for _, child := range node.Content {
+		checkMergeTags(file, child, configErrors)
+	}
+}
+
+// clearMergeTags removes the merge directives once inheritance is resolved, so lists decode normally.
+func clearMergeTags(node *yaml.Node) {
+	if node.Tag == appendTag || node.Tag == replaceTag {
+		node.Tag = ""
+	}
+	for _, child := range node.Content {
+		clearMergeTags(child)
+	}
+}
@@ -0,0 +1,118 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// referencePattern matches ${env:VAR}, ${env:VAR:-default} and ${file:path}. EMR parameter
+// placeholders such as ${MinExecutors} cannot contain a colon, so they are left untouched.
+var referencePattern = regexp.MustCompile(`\$\{(env|file):([^}]*)\}`)
+
+// envReferencePattern splits the body of an env reference into the name and the optional default.
+var envReferencePattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(:-(.*))?$`)
+
+// interpolator replaces the env and file references in the string values of a document.
+type interpolator struct {
+	file         string
+	lookupEnv    func(string) (string, bool)
+	configErrors *ConfigErrors
+}
+
+// interpolateDocument replaces the references in a configuration file. Only the overlay of the
+// selected environment is interpolated, so variables of the other environments need not be set.
+func (i *interpolator) interpolateDocument(document *yaml.Node, environment string) {
+	root := document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		i.interpolate(root, "")
+
+		return
+	}
+
+	for j := 0; j+1 < len(root.Content); j += 2 {
+		key, value := root.Content[j], root.Content[j+1]
+		if key.Value != environmentsKey {
+			i.interpolate(value, key.Value)
+
+			continue
+		}
+		if overlay := mappingValue(value, environment); environment != "" && overlay != nil {
+			i.interpolate(overlay, environmentsKey+"."+environment)
+		}
+	}
+}
+
+// interpolate walks a node and replaces the references in every scalar value.
+func (i *interpolator) interpolate(node *yaml.Node, path string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			i.interpolate(child, path)
+		}
+	case yaml.MappingNode:
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			i.interpolate(node.Content[j+1], joinFieldPath(path, node.Content[j].Value))
+		}
+	case yaml.SequenceNode:
+		for j, child := range node.Content {
+			i.interpolate(child, fmt.Sprintf("%s[%d]", path, j))
+		}
+	case yaml.ScalarNode:
+		if strings.Contains(node.Value, "${") {
+			node.Value = referencePattern.ReplaceAllStringFunc(node.Value, func(reference string) string {
+				value, err := i.resolve(reference)
+				if err != nil {
+					*i.configErrors = append(*i.configErrors, newConfigError(i.file, node, "%s: %v", path, err))
+				}
+
+				return value
+			})
+		}
+	}
+}
+
+// resolve returns the value of a single reference.
+func (i *interpolator) resolve(reference string) (string, error) {
+	match := referencePattern.FindStringSubmatch(reference)
+	kind, body := match[1], match[2]
+
+	if kind == "file" {
+		if body == "" {
+			return "", fmt.Errorf("%s names no file", reference)
+		}
+		path := body
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(i.file), path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read %s: %w", reference, err)
+		}
+
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	parts := envReferencePattern.FindStringSubmatch(body)
+	if parts == nil {
+		return "", fmt.Errorf("%s is not a valid environment variable reference", reference)
+	}
+	name, hasDefault, defaultValue := parts[1], parts[2] != "", parts[3]
+
+	value, ok := i.lookupEnv(name)
+	switch {
+	case hasDefault && value == "":
+		return defaultValue, nil
+	case !ok:
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,105 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// placeholderPattern matches EMR job template parameter references such as ${MinExecutors}.
+var placeholderPattern = regexp.MustCompile(`\$\{([A-Za-z0-9._/#-]+)\}`)
+
+// placeholderSkippedFields are not sent as template content, so they cannot reference parameters.
+var placeholderSkippedFields = map[string]bool{"parameter_configuration": true, "ssm_parameters": true, "outputs": true, "smoke_test": true}
+
+// Placeholders returns every ${Param} placeholder referenced by the string fields of a job template,
+// mapped to the sorted field paths that reference it.
+func Placeholders(jobTemplate JobTemplateConfig) map[string][]string {
+	placeholders := make(map[string][]string)
+	collectPlaceholders(reflect.ValueOf(jobTemplate), "", placeholders)
+
+	for _, fields := range placeholders {
+		sort.Strings(fields)
+	}
+
+	return placeholders
+}
+
+// collectPlaceholders walks a value and records the placeholders found in strings and map keys.
+func collectPlaceholders(value reflect.Value, path string, placeholders map[string][]string) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			collectPlaceholders(value.Elem(), path, placeholders)
+		}
+	case reflect.String:
+		for _, match := range placeholderPattern.FindAllStringSubmatch(value.String(), -1) {
+			placeholders[match[1]] = append(placeholders[match[1]], path)
+		}
+	case reflect.Struct:
+		for name, field := range yamlFieldIndexes(value.Type()) {
+			if path == "" && placeholderSkippedFields[name] {
+				continue
+			}
+			collectPlaceholders(value.Field(field), joinFieldPath(path, name), placeholders)
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			collectPlaceholders(value.Index(i), fmt.Sprintf("%s[%d]", path, i), placeholders)
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			fieldPath := joinFieldPath(path, fmt.Sprint(iter.Key().Interface()))
+			collectPlaceholders(iter.Key(), fieldPath, placeholders)
+			collectPlaceholders(iter.Value(), fieldPath, placeholders)
+		}
+	}
+}
+
+// yamlFieldIndexes maps the yaml keys of a struct type to the field indexes.
+func yamlFieldIndexes(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "-" && name != "" {
+			fields[name] = i
+		}
+	}
+
+	return fields
+}
+
+// joinFieldPath appends a field name to a dotted field path.
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// validatePlaceholders cross-checks the ${Param} placeholders against parameter_configuration.
+// Undeclared placeholders are errors, declared parameters that are never referenced are warnings.
+// Parameters inherited from defaults or a base template are shared by several job templates and
+// may be unused by some of them, so only the entry's own declarations are warned about.
+func validatePlaceholders(jobTemplate JobTemplateConfig, addError, addWarning func(field, format string, args ...any)) {
+	placeholders := Placeholders(jobTemplate)
+
+	for _, name := range sortedKeys(placeholders) {
+		if _, ok := jobTemplate.ParameterConfiguration[name]; !ok {
+			for _, field := range placeholders[name] {
+				addError(field, "placeholder ${%s} is not declared in parameter_configuration", name)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(jobTemplate.ParameterConfiguration) {
+		if _, ok := placeholders[name]; !ok && !slices.Contains(jobTemplate.InheritedParameters, name) {
+			addWarning("parameter_configuration."+name, "parameter is declared but never referenced")
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package template
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// schemaDialect is the JSON Schema version of the generated schema.
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches the Go durations accepted for timeouts, such as 90s or 1h30m.
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// schemaEnums lists the allowed values of fields checked by Validate, keyed by type and yaml key.
+var schemaEnums = map[string][]string{
+	"TemplateParameterConfiguration.type": {"STRING", "NUMBER"},
+	"JobTemplateConfig.persistent_app_ui": {"ENABLED", "DISABLED"},
+	"SSMParameter.type":                   {"String", "SecureString"},
+	"SSMParameter.tier":                   {"Standard", "Advanced", "Intelligent-Tiering"},
+	"OutputConfig.type":                   {OutputTypeManifest, OutputTypeDotenv, OutputTypeStdout},
+	"OutputConfig.format":                 {"json", "yaml"},
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// schemaGenerator builds the definitions of the struct types reachable from the configuration file.
+type schemaGenerator struct {
+	defs map[string]any
+}
+
+// JSONSchema returns a JSON Schema describing configuration files, for editors and pre-commit
+// hooks. It is generated from the configuration types, so it follows their yaml keys. YAML merge
+// directives such as !append and env or file references are not visible to the schema.
+func JSONSchema() ([]byte, error) {
+	g := &schemaGenerator{defs: make(map[string]any)}
+	root := g.object(reflect.TypeOf(configFile{}))
+	root["$schema"] = schemaDialect
+	root["title"] = "EMR on EKS job templates"
+	root["required"] = []string{"job_templates"}
+	root["$defs"] = g.defs
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// object describes a struct type with one property per yaml key.
+func (g *schemaGenerator) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = strings.ToLower(field.Name)
+		}
+
+		property := g.schema(field.Type)
+		if values, ok := schemaEnums[t.Name()+"."+name]; ok {
+			property["enum"] = values
+		}
+		if name == "job_templates" {
+			// Job templates need a name; defaults and base templates must not have one.
+			property["items"] = map[string]any{"$ref": property["items"].(map[string]any)["$ref"], "required": []string{"name"}}
+		}
+		properties[name] = property
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// schema describes a field type, adding struct types to the definitions on first use.
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		return map[string]any{"type": "string", "pattern": durationPattern}
+	case t.Kind() == reflect.Struct:
+		// Unexported layouts such as environmentOverlay get an exported looking name.
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := g.defs[name]; !ok {
+			// Reserve the name first, so recursive types terminate.
+			g.defs[name] = nil
+			g.defs[name] = g.object(t)
+		}
+		ref := map[string]any{"$ref": "#/$defs/" + name}
+		if acceptsScalar(t) {
+			return map[string]any{"anyOf": []any{map[string]any{"type": "string"}, ref}}
+		}
+
+		return ref
+	case t.Kind() == reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case t.Kind() == reflect.Slice:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
@@ -4,48 +4,257 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
-	"gopkg.in/yaml.v2"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
+// Keys of the Spark submit parameters block; the misspelled one is a deprecated alias.
+const (
+	sparkSubmitParametersKey           = "spark_submit_parameters"
+	deprecatedSparkSubmitParametersKey = "spark_submit_pararmeters"
+)
+
+// logger reports non-fatal configuration problems such as deprecated keys.
+var logger logrus.FieldLogger = logrus.StandardLogger()
+
+// SetLogger sets the logger used to report configuration warnings.
+func SetLogger(l logrus.FieldLogger) {
+	logger = l
+}
+
 type TemplateParameterConfiguration struct {
-	DefaultValue *string                         `yaml:"default_value"`
-	Type         types.TemplateParameterDataType `yaml:"type"`
+	DefaultValue *string                         `yaml:"default_value,omitempty"`
+	Type         types.TemplateParameterDataType `yaml:"type,omitempty"`
 }
 
 type ApplicationConfiguration struct {
-	Classification string            `yaml:"classification"`
-	Properties     map[string]string `yaml:"properties"`
+	Classification string            `yaml:"classification,omitempty"`
+	Properties     map[string]string `yaml:"properties,omitempty"`
 }
 
 type SparkSubmitParameters struct {
-	Master     string   `yaml:"master"`
-	DeployMode string   `yaml:"deploy_mode"`
-	Class      string   `yaml:"class"`
-	Conf       []string `yaml:"conf"`
-	Packages   string   `yaml:"packages"`
+	Master     string   `yaml:"master,omitempty"`
+	DeployMode string   `yaml:"deploy_mode,omitempty"`
+	Class      string   `yaml:"class,omitempty"`
+	Conf       []string `yaml:"conf,omitempty"`
+	Packages   string   `yaml:"packages,omitempty"`
+	// ExtraArgs are passed through after the other parameters, e.g. ["--num-executors", "4"].
+	ExtraArgs []string `yaml:"extra_args,omitempty"`
+}
+
+// SSMParameter is an SSM parameter receiving the job template ID. In YAML it is either a plain
+// parameter name or a mapping with the name and the metadata applied when the parameter is created.
+type SSMParameter struct {
+	Name           string            `yaml:"name,omitempty"`
+	Type           string            `yaml:"type,omitempty"`
+	KeyID          string            `yaml:"key_id,omitempty"`
+	Tier           string            `yaml:"tier,omitempty"`
+	Description    string            `yaml:"description,omitempty"`
+	Tags           map[string]string `yaml:"tags,omitempty"`
+	AllowedPattern string            `yaml:"allowed_pattern,omitempty"`
+}
+
+// UnmarshalYAML accepts both the plain name and the mapping form.
+func (p *SSMParameter) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*p = SSMParameter{Name: node.Value}
+
+		return nil
+	}
+
+	type plain SSMParameter
+
+	return node.Decode((*plain)(p))
+}
+
+// MarshalYAML writes the plain name form when the parameter has no metadata.
+func (p SSMParameter) MarshalYAML() (any, error) {
+	if reflect.DeepEqual(p, SSMParameter{Name: p.Name}) {
+		return p.Name, nil
+	}
+
+	type plain SSMParameter
+
+	return plain(p), nil
+}
+
+// Output types publishing the job template ID next to the SSM parameters.
+const (
+	OutputTypeManifest = "manifest"
+	OutputTypeDotenv   = "dotenv"
+	OutputTypeStdout   = "stdout"
+)
+
+// OutputConfig is an additional destination of the created job template ID.
+// Manifest and dotenv outputs write to Path, or to the file named by the PathEnv
+// environment variable such as GITHUB_OUTPUT.
+type OutputConfig struct {
+	Type    string `yaml:"type,omitempty"`
+	Path    string `yaml:"path,omitempty"`
+	PathEnv string `yaml:"path_env,omitempty"`
+	Format  string `yaml:"format,omitempty"`
+	Key     string `yaml:"key,omitempty"`
+}
+
+// SmokeTestConfig describes a short job run started from a newly created job template before
+// its SSM parameters are updated. The parameters override the job template parameters.
+type SmokeTestConfig struct {
+	VirtualClusterID string            `yaml:"virtual_cluster_id,omitempty"`
+	Parameters       map[string]string `yaml:"parameters,omitempty"`
+	Timeout          time.Duration     `yaml:"timeout,omitempty"`
 }
 
 type JobTemplateConfig struct {
-	Name                      string                                    `yaml:"name"`
-	ExecutionRoleArn          string                                    `yaml:"execution_role_arn"`
-	ReleaseLabel              string                                    `yaml:"release_label"`
-	EntryPoint                string                                    `yaml:"entry_point"`
-	EntryPointArguments       []string                                  `yaml:"entry_point_arguments"`
-	Tags                      map[string]string                         `yaml:"tags"`
-	SparkSubmitParameters     SparkSubmitParameters                     `yaml:"spark_submit_pararmeters"`
-	PersistentAppUI           string                                    `yaml:"persistent_app_ui"`
-	LogGroupName              string                                    `yaml:"log_group_name"`
-	ParameterConfiguration    map[string]TemplateParameterConfiguration `yaml:"parameter_configuration"`
-	ApplicationConfigurations []ApplicationConfiguration                `yaml:"application_configurations"`
+	Name                      string                                    `yaml:"name,omitempty"`
+	ExecutionRoleArn          string                                    `yaml:"execution_role_arn,omitempty"`
+	ReleaseLabel              string                                    `yaml:"release_label,omitempty"`
+	EntryPoint                string                                    `yaml:"entry_point,omitempty"`
+	EntryPointArguments       []string                                  `yaml:"entry_point_arguments,omitempty"`
+	Tags                      map[string]string                         `yaml:"tags,omitempty"`
+	SparkSubmitParameters     SparkSubmitParameters                     `yaml:"spark_submit_parameters,omitempty"`
+	PersistentAppUI           string                                    `yaml:"persistent_app_ui,omitempty"`
+	LogGroupName              string                                    `yaml:"log_group_name,omitempty"`
+	ParameterConfiguration    map[string]TemplateParameterConfiguration `yaml:"parameter_configuration,omitempty"`
+	ApplicationConfigurations []ApplicationConfiguration                `yaml:"application_configurations,omitempty"`
+	SSMParameters             []SSMParameter                            `yaml:"ssm_parameters,omitempty"`
+	Outputs                   []OutputConfig                            `yaml:"outputs,omitempty"`
+	SmokeTest                 *SmokeTestConfig                          `yaml:"smoke_test,omitempty"`
+	// Extends names the base template the entry inherits from; it is resolved, and empty, once loaded.
+	Extends string `yaml:"extends,omitempty"`
+	// SourceFile and SourceLine locate the entry in the configuration files.
+	SourceFile string `yaml:"-"`
+	SourceLine int    `yaml:"-"`
+	// InheritedParameters are the sorted parameter_configuration keys the entry only has from
+	// defaults or its base template.
+	InheritedParameters []string `yaml:"-"`
+}
+
+// SSMParameterNames returns the names of the SSM parameters mapped to the job template.
+func (j JobTemplateConfig) SSMParameterNames() []string {
+	names := make([]string, 0, len(j.SSMParameters))
+	for _, param := range j.SSMParameters {
+		names = append(names, param.Name)
+	}
+
+	return names
 }
 
 type Config struct {
+	// Environment is the environment whose overlay was applied, if any.
+	// It is not rendered, the rendered file has the overlay applied and the Environment tag set.
+	Environment  string              `yaml:"-"`
 	JobTemplates []JobTemplateConfig `yaml:"job_templates"`
 }
 
-func LoadConfig(filePath string) (*Config, error) {
+// ConfigError describes a problem at a position in a configuration file. A zero Column means
+// the problem concerns the whole line, a zero Line that the position is unknown.
+type ConfigError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	if e.Column == 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// ConfigErrors collects every problem found in a configuration file.
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, configError := range e {
+		messages = append(messages, configError.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// newConfigError returns a ConfigError positioned at the given node.
+func newConfigError(file string, node *yaml.Node, format string, args ...any) ConfigError {
+	return ConfigError{File: file, Line: node.Line, Column: node.Column, Message: fmt.Sprintf(format, args...)}
+}
+
+// loadFile loads a single configuration file and reports whether it has an overlay for the
+// selected environment.
+func loadFile(filePath string, options loadOptions) (*Config, bool, error) {
+	document, err := readDocument(filePath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Reject unknown keys up front so typos do not silently produce empty fields.
+	var configErrors ConfigErrors
+	renameDeprecatedKeys(filePath, document, &configErrors)
+	checkKnownFields(filePath, document, reflect.TypeOf(configFile{}), &configErrors)
+	interpolator := &interpolator{file: filePath, lookupEnv: options.lookupEnv, configErrors: &configErrors}
+	interpolator.interpolateDocument(document, options.environment)
+	found, err := applyEnvironment(filePath, document, options, &configErrors)
+	if err != nil {
+		return nil, false, err
+	}
+	lines := jobTemplateLines(document)
+	ownParameters := ownParameterKeys(document)
+	resolveInheritance(filePath, document, &configErrors)
+	if len(configErrors) > 0 {
+		return nil, false, configErrors
+	}
+
+	var config Config
+	err = document.Decode(&config)
+	if err != nil {
+		return nil, false, fmt.Errorf("unmarshal func returned error: %s: %w", filePath, err)
+	}
+
+	for i := range config.JobTemplates {
+		config.JobTemplates[i].SourceFile = filePath
+		if i < len(lines) {
+			config.JobTemplates[i].SourceLine = lines[i]
+		}
+		if i < len(ownParameters) {
+			config.JobTemplates[i].InheritedParameters = inheritedParameters(config.JobTemplates[i].ParameterConfiguration, ownParameters[i])
+		}
+	}
+
+	return &config, found, nil
+}
+
+// jobTemplateLines returns the line of every job template entry of a document.
+func jobTemplateLines(document *yaml.Node) []int {
+	root := document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	jobTemplates := mappingValue(root, "job_templates")
+	if jobTemplates == nil || jobTemplates.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	lines := make([]int, 0, len(jobTemplates.Content))
+	for _, jobTemplate := range jobTemplates.Content {
+		lines = append(lines, jobTemplate.Line)
+	}
+
+	return lines
+}
+
+// readDocument reads and parses a configuration file into a YAML document. The format follows
+// the extension: JSON and TOML files are decoded into the same document as YAML files.
+func readDocument(filePath string) (*yaml.Node, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("opening file func returned error:%w", err)
@@ -57,11 +266,213 @@ func LoadConfig(filePath string) (*Config, error) {
 		return nil, fmt.Errorf("read all func returned error:%w", err)
 	}
 
-	var config Config
-	err = yaml.Unmarshal(data, &config)
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return jsonDocument(filePath, data)
+	case ".toml":
+		return tomlDocument(filePath, data)
+	}
+
+	var document yaml.Node
+	err = yaml.Unmarshal(data, &document)
 	if err != nil {
-		return nil, fmt.Errorf("unmarshal func returned error:%w", err)
+		return nil, fmt.Errorf("unmarshal func returned error: %s: %w", filePath, err)
+	}
+
+	return &document, nil
+}
+
+// renameDeprecatedKeys rewrites deprecated job template keys to their current spelling and logs a warning.
+func renameDeprecatedKeys(file string, document *yaml.Node, configErrors *ConfigErrors) {
+	root := document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+
+	for _, jobTemplate := range templateNodes(root) {
+		deprecated := mappingKey(jobTemplate, deprecatedSparkSubmitParametersKey)
+		if deprecated == nil {
+			continue
+		}
+
+		if current := mappingKey(jobTemplate, sparkSubmitParametersKey); current != nil {
+			*configErrors = append(*configErrors, newConfigError(file, deprecated,
+				"both %s and deprecated %s are set, remove %s", sparkSubmitParametersKey, deprecatedSparkSubmitParametersKey, deprecatedSparkSubmitParametersKey))
+			removeMappingKey(jobTemplate, deprecatedSparkSubmitParametersKey)
+
+			continue
+		}
+
+		logger.Warnf("%s:%d:%d: %s is deprecated, use %s", file, deprecated.Line, deprecated.Column, deprecatedSparkSubmitParametersKey, sparkSubmitParametersKey)
+		deprecated.Value = sparkSubmitParametersKey
+	}
+}
+
+// templateNodes returns the job templates of a document together with the defaults, the base
+// templates and the job templates of the environment overlays.
+func templateNodes(root *yaml.Node) []*yaml.Node {
+	var nodes []*yaml.Node
+	if environments := mappingValue(root, environmentsKey); environments != nil && environments.Kind == yaml.MappingNode {
+		for i := 1; i < len(environments.Content); i += 2 {
+			nodes = append(nodes, templateNodes(environments.Content[i])...)
+		}
+	}
+	if defaults := mappingValue(root, defaultsKey); defaults != nil {
+		nodes = append(nodes, defaults)
+	}
+	if baseTemplates := mappingValue(root, baseTemplatesKey); baseTemplates != nil && baseTemplates.Kind == yaml.MappingNode {
+		for i := 1; i < len(baseTemplates.Content); i += 2 {
+			nodes = append(nodes, baseTemplates.Content[i])
+		}
+	}
+	if jobTemplates := mappingValue(root, "job_templates"); jobTemplates != nil && jobTemplates.Kind == yaml.SequenceNode {
+		nodes = append(nodes, jobTemplates.Content...)
+	}
+
+	return nodes
+}
+
+// mappingKey returns the key node with the given name in a mapping node, or nil.
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+
+	return nil
+}
+
+// removeMappingKey deletes the key and its value from a mapping node.
+func removeMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+
+			return
+		}
+	}
+}
+
+// mappingValue returns the value node with the given key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// checkKnownFields walks the YAML tree alongside the Go type it decodes into and records
+// every mapping key that does not match a yaml struct tag and every mismatched node kind.
+func checkKnownFields(file string, node *yaml.Node, t reflect.Type, configErrors *ConfigErrors) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			checkKnownFields(file, child, t, configErrors)
+		}
+	case yaml.AliasNode:
+		checkKnownFields(file, node.Alias, t, configErrors)
+	case yaml.MappingNode:
+		checkMapping(file, node, t, configErrors)
+	case yaml.SequenceNode:
+		checkSequence(file, node, t, configErrors)
+	case yaml.ScalarNode:
+		isComposite := t.Kind() == reflect.Struct || t.Kind() == reflect.Map || t.Kind() == reflect.Slice
+		if isComposite && node.Tag != "!!null" && !acceptsScalar(t) {
+			*configErrors = append(*configErrors, newConfigError(file, node, "expected %s, found %q", kindName(t), node.Value))
+		}
+	}
+}
+
+// checkMapping checks a mapping node decoded into a struct or a map.
+func checkMapping(file string, node *yaml.Node, t reflect.Type, configErrors *ConfigErrors) {
+	switch t.Kind() {
+	case reflect.Struct:
+		checkStructFields(file, node, t, configErrors)
+	case reflect.Map:
+		for i := 1; i < len(node.Content); i += 2 {
+			checkKnownFields(file, node.Content[i], t.Elem(), configErrors)
+		}
+	default:
+		*configErrors = append(*configErrors, newConfigError(file, node, "expected %s, found a mapping", kindName(t)))
+	}
+}
+
+// checkStructFields records the keys of a mapping node without a matching yaml struct tag and
+// checks the values of the others against their field types.
+func checkStructFields(file string, node *yaml.Node, t reflect.Type, configErrors *ConfigErrors) {
+	fields := yamlFields(t)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		fieldType, ok := fields[key.Value]
+		if !ok {
+			*configErrors = append(*configErrors, newConfigError(file, key, "unknown field %q in %s", key.Value, t.Name()))
+
+			continue
+		}
+		checkKnownFields(file, value, fieldType, configErrors)
+	}
+}
+
+// checkSequence checks a sequence node decoded into a slice.
+func checkSequence(file string, node *yaml.Node, t reflect.Type, configErrors *ConfigErrors) {
+	if t.Kind() != reflect.Slice {
+		*configErrors = append(*configErrors, newConfigError(file, node, "expected %s, found a list", kindName(t)))
+
+		return
+	}
+	for _, child := range node.Content {
+		checkKnownFields(file, child, t.Elem(), configErrors)
+	}
+}
+
+// yamlUnmarshalerType is the interface of types decoding their own YAML.
+var yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// acceptsScalar reports whether a composite type also has a single value form, like SSMParameter.
+func acceptsScalar(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(yamlUnmarshalerType)
+}
+
+// kindName describes the YAML shape expected for a Go type.
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "a mapping"
+	case reflect.Slice:
+		return "a list"
+	default:
+		return "a single value"
+	}
+}
+
+// yamlFields maps the yaml keys of a struct type to the field types.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
 	}
 
-	return &config, nil
+	return fields
 }
@@ -1,13 +1,21 @@
 package template_test
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/GoGstickGo/emr-containers-template/template"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// ignoreSource compares job templates loaded from different files.
+var ignoreSource = cmpopts.IgnoreFields(template.JobTemplateConfig{}, "SourceFile", "SourceLine")
+
 func TestLoadConfig(t *testing.T) {
 	t.Parallel()
 	type args struct {
@@ -45,12 +53,17 @@ func TestLoadConfig(t *testing.T) {
 							Conf: []string{
 								"spark.dynamicAllocation.shuffleTracking.enabled=true",
 								"spark.dynamicAllocation.minExecutors=${MinExecutors}",
+								"spark.dynamicAllocation.maxExecutors=${MaxExecutors}",
 							},
 							Packages: "org.reactivestreams:reactive-streams:1.0.4,io.projectreactor:reactor-core:3.6.6",
 						},
 						PersistentAppUI: "DISABLED",
 						LogGroupName:    "my-log-group",
 						ParameterConfiguration: map[string]template.TemplateParameterConfiguration{
+							"MinExecutors": {
+								DefaultValue: aws.String("1"),
+								Type:         "NUMBER",
+							},
 							"MaxExecutors": {
 								DefaultValue: aws.String("10"),
 								Type:         "NUMBER",
@@ -69,6 +82,9 @@ func TestLoadConfig(t *testing.T) {
 								},
 							},
 						},
+						SSMParameters: []template.SSMParameter{{Name: "/emr/custom-job/template-id"}},
+						SourceFile:    "testdata/valid_config.yaml",
+						SourceLine:    2,
 					},
 				},
 			},
@@ -99,3 +115,361 @@ func TestLoadConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfig_DeprecatedSparkSubmitKey(t *testing.T) {
+	t.Parallel()
+
+	want, err := template.LoadConfig("testdata/valid_config.yaml")
+	require.NoError(t, err)
+
+	got, err := template.LoadConfig("testdata/deprecated_config.yaml")
+	require.NoError(t, err)
+
+	if diff := cmp.Diff(got, want, ignoreSource); diff != "" {
+		t.Errorf("deprecated key must decode like the current one, diff ==> %v\n,", diff)
+	}
+}
+
+func TestLoadConfig_SSMParameters(t *testing.T) {
+	t.Parallel()
+
+	got, err := template.LoadConfig("testdata/ssm_parameters.yaml")
+	require.NoError(t, err)
+	require.Len(t, got.JobTemplates, 1)
+
+	assert.Equal(t, []template.SSMParameter{
+		{Name: "/emr/custom-job/template-id"},
+		{
+			Name:           "/emr/custom-job/secure-template-id",
+			Type:           "SecureString",
+			KeyID:          "alias/emr-pointers",
+			Tier:           "Advanced",
+			Description:    "Current custom-job template ID",
+			AllowedPattern: "^[a-z0-9]+$",
+			Tags:           map[string]string{"Owner": "team-x"},
+		},
+	}, got.JobTemplates[0].SSMParameters)
+	assert.Equal(t, []string{"/emr/custom-job/template-id", "/emr/custom-job/secure-template-id"}, got.JobTemplates[0].SSMParameterNames())
+}
+
+func TestLoadConfig_SmokeTest(t *testing.T) {
+	t.Parallel()
+
+	got, err := template.LoadConfig("testdata/smoke_test.yaml")
+	require.NoError(t, err)
+	require.Len(t, got.JobTemplates, 1)
+
+	assert.Equal(t, &template.SmokeTestConfig{
+		VirtualClusterID: "vc-smoke",
+		Parameters:       map[string]string{"MaxExecutors": "1"},
+		Timeout:          5 * time.Minute,
+	}, got.JobTemplates[0].SmokeTest)
+}
+
+func TestLoadConfig_Extends(t *testing.T) {
+	t.Parallel()
+
+	want, err := template.LoadConfig("testdata/valid_config.yaml")
+	require.NoError(t, err)
+
+	got, err := template.LoadConfig("testdata/extends.yaml")
+	require.NoError(t, err)
+
+	ignoreInherited := cmpopts.IgnoreFields(template.JobTemplateConfig{}, "InheritedParameters")
+	if diff := cmp.Diff(want, got, ignoreSource, ignoreInherited); diff != "" {
+		t.Errorf("LoadConfig() mismatch (-want +got):\n%s", diff)
+	}
+	assert.Equal(t, []string{"MinExecutors"}, got.JobTemplates[0].InheritedParameters)
+}
+
+func TestLoadConfig_Formats(t *testing.T) {
+	t.Parallel()
+
+	want, err := template.LoadConfig("testdata/valid_config.yaml")
+	require.NoError(t, err)
+
+	for _, filePath := range []string{"testdata/valid_config.json", "testdata/valid_config.toml"} {
+		t.Run(filePath, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := template.LoadConfig(filePath)
+			require.NoError(t, err)
+			if diff := cmp.Diff(want, got, ignoreSource); diff != "" {
+				t.Errorf("LoadConfig(%s) mismatch (-want +got):\n%s", filePath, diff)
+			}
+			assert.Equal(t, filePath, got.JobTemplates[0].SourceFile)
+		})
+	}
+}
+
+func TestLoadConfig_StrictErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		filePath string
+		want     template.ConfigErrors
+	}{
+		{
+			name:     "Unknown Fields And Wrong Shapes",
+			filePath: "testdata/unknown_field.yaml",
+			want: template.ConfigErrors{
+				{File: "testdata/unknown_field.yaml", Line: 4, Column: 5, Message: `unknown field "release_lable" in JobTemplateConfig`},
+				{File: "testdata/unknown_field.yaml", Line: 7, Column: 7, Message: `unknown field "deploy-mode" in SparkSubmitParameters`},
+				{File: "testdata/unknown_field.yaml", Line: 9, Column: 7, Message: "expected a mapping, found a list"},
+				{File: "testdata/unknown_field.yaml", Line: 13, Column: 9, Message: `unknown field "kms_key" in SSMParameter`},
+			},
+		},
+		{
+			name:     "JSON Unknown Field",
+			filePath: "testdata/unknown_field.json",
+			want: template.ConfigErrors{
+				{File: "testdata/unknown_field.json", Line: 5, Column: 7, Message: `unknown field "release_lable" in JobTemplateConfig`},
+			},
+		},
+		{
+			name:     "TOML Unknown Field",
+			filePath: "testdata/unknown_field.toml",
+			want: template.ConfigErrors{
+				{File: "testdata/unknown_field.toml", Message: `unknown field "release_lable" in JobTemplateConfig`},
+			},
+		},
+		{
+			name:     "Both Spark Submit Keys",
+			filePath: "testdata/duplicate_spark_config.yaml",
+			want: template.ConfigErrors{
+				{File: "testdata/duplicate_spark_config.yaml", Line: 5, Column: 5, Message: "both spark_submit_parameters and deprecated spark_submit_pararmeters are set, remove spark_submit_pararmeters"},
+			},
+		},
+		{
+			name:     "Inheritance",
+			filePath: "testdata/extends_errors.yaml",
+			want: template.ConfigErrors{
+				{File: "testdata/extends_errors.yaml", Line: 9, Column: 20, Message: "!append can only be used on a list"},
+				{File: "testdata/extends_errors.yaml", Line: 2, Column: 3, Message: "name cannot be set in defaults"},
+				{File: "testdata/extends_errors.yaml", Line: 7, Column: 14, Message: "base templates extend each other: first -> second -> first"},
+				{File: "testdata/extends_errors.yaml", Line: 12, Column: 14, Message: `unknown base template "missing"`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := template.LoadConfig(tt.filePath)
+			assert.Nil(t, got)
+
+			var configErrors template.ConfigErrors
+			require.ErrorAs(t, err, &configErrors)
+			assert.Equal(t, tt.want, configErrors)
+		})
+	}
+}
+
+func TestLoadConfig_Environments(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		environment string
+		want        template.JobTemplateConfig
+	}{
+		{
+			name: "No Environment",
+			want: template.JobTemplateConfig{
+				Name:                  "custom-job",
+				ExecutionRoleArn:      "arn:aws:iam::111111111111:role/emr-dev",
+				ReleaseLabel:          "emr-6.4.0-latest",
+				LogGroupName:          "emr-dev",
+				SparkSubmitParameters: template.SparkSubmitParameters{Conf: []string{"spark.executor.instances=1"}},
+				Tags:                  map[string]string{"Environment": "dev"},
+				SourceFile:            "testdata/environments.yaml",
+				SourceLine:            7,
+			},
+		},
+		{
+			name:        "Inline Overlay",
+			environment: "staging",
+			want: template.JobTemplateConfig{
+				Name:                  "custom-job",
+				ExecutionRoleArn:      "arn:aws:iam::222222222222:role/emr-staging",
+				ReleaseLabel:          "emr-6.4.0-latest",
+				LogGroupName:          "emr-dev",
+				SparkSubmitParameters: template.SparkSubmitParameters{Conf: []string{"spark.executor.instances=1"}},
+				Tags:                  map[string]string{"Environment": "staging"},
+				SourceFile:            "testdata/environments.yaml",
+				SourceLine:            7,
+			},
+		},
+		{
+			name:        "Inline Overlay And Overlay File",
+			environment: "prod",
+			want: template.JobTemplateConfig{
+				Name:             "custom-job",
+				ExecutionRoleArn: "arn:aws:iam::333333333333:role/emr-prod",
+				ReleaseLabel:     "emr-6.4.0-latest",
+				LogGroupName:     "emr-prod",
+				SparkSubmitParameters: template.SparkSubmitParameters{
+					Conf: []string{"spark.executor.instances=1", "spark.executor.memory=8G"},
+				},
+				Tags:       map[string]string{"Environment": "prod", "CostCenter": "1234"},
+				SourceFile: "testdata/environments.yaml",
+				SourceLine: 7,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := template.LoadConfig("testdata/environments.yaml", template.WithEnvironment(tt.environment))
+			require.NoError(t, err)
+			assert.Equal(t, tt.environment, got.Environment)
+			assert.Equal(t, []template.JobTemplateConfig{tt.want}, got.JobTemplates)
+		})
+	}
+}
+
+func TestLoadConfig_EnvironmentErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := template.LoadConfig("testdata/environments.yaml", template.WithEnvironment("qa"))
+	require.EqualError(t, err, `unknown environment "qa": not in environments and testdata/environments.qa.yaml does not exist`)
+
+	_, err = template.LoadConfig("testdata/environments.yaml", template.WithEnvironment("../prod"))
+	require.EqualError(t, err, `invalid environment name "../prod"`)
+
+	_, err = template.LoadConfig("testdata/environments.yaml", template.WithEnvironment("broken"))
+	var configErrors template.ConfigErrors
+	require.ErrorAs(t, err, &configErrors)
+	assert.Equal(t, template.ConfigErrors{
+		{File: "testdata/environments.yaml", Line: 27, Column: 15, Message: `job template "missing-job" of environment "broken" does not exist`},
+	}, configErrors)
+}
+
+func TestLoadConfig_Interpolation(t *testing.T) {
+	t.Parallel()
+	lookupEnv := func(env map[string]string) template.LoadOption {
+		return template.WithLookupEnv(func(name string) (string, bool) {
+			value, ok := env[name]
+
+			return value, ok
+		})
+	}
+
+	got, err := template.LoadConfig("testdata/interpolation.yaml", lookupEnv(map[string]string{"ACCOUNT_ID": "123456789012", "LOG_GROUP": ""}))
+	require.NoError(t, err)
+	require.Len(t, got.JobTemplates, 1)
+	jobTemplate := got.JobTemplates[0]
+	assert.Equal(t, "arn:aws:iam::123456789012:role/CustomRole", jobTemplate.ExecutionRoleArn)
+	assert.Equal(t, "s3://default-bucket/script.py", jobTemplate.EntryPoint)
+	assert.Empty(t, jobTemplate.LogGroupName)
+	assert.Equal(t, "org.reactivestreams:reactive-streams:1.0.4", jobTemplate.SparkSubmitParameters.Packages)
+	assert.Equal(t, []string{"spark.dynamicAllocation.maxExecutors=${MaxExecutors}"}, jobTemplate.SparkSubmitParameters.Conf)
+
+	_, err = template.LoadConfig("testdata/interpolation.yaml", lookupEnv(map[string]string{"BUCKET": "bucket"}), template.WithEnvironment("prod"))
+	var configErrors template.ConfigErrors
+	require.ErrorAs(t, err, &configErrors)
+	assert.Equal(t, template.ConfigErrors{
+		{File: "testdata/interpolation.yaml", Line: 3, Column: 25, Message: "job_templates[0].execution_role_arn: environment variable ACCOUNT_ID is not set"},
+		{File: "testdata/interpolation.yaml", Line: 5, Column: 21, Message: "job_templates[0].log_group_name: environment variable LOG_GROUP is not set"},
+		{File: "testdata/interpolation.yaml", Line: 13, Column: 22, Message: "environments.prod.defaults.release_label: environment variable PROD_RELEASE is not set"},
+	}, configErrors)
+}
+
+func TestLoadConfig_MultipleFiles(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		path        string
+		environment string
+		want        []template.JobTemplateConfig
+	}{
+		{
+			name: "Directory",
+			path: "testdata/templates",
+			want: []template.JobTemplateConfig{
+				{Name: "etl", ReleaseLabel: "emr-6.4.0-latest", LogGroupName: "etl", SourceFile: "testdata/templates/etl.yaml", SourceLine: 4},
+				{Name: "reports", LogGroupName: "reports", SourceFile: "testdata/templates/reports.yml", SourceLine: 2},
+			},
+		},
+		{
+			name: "Glob",
+			path: "testdata/templates/*.yml",
+			want: []template.JobTemplateConfig{
+				{Name: "reports", LogGroupName: "reports", SourceFile: "testdata/templates/reports.yml", SourceLine: 2},
+			},
+		},
+		{
+			name:        "Overlay Of One File",
+			path:        "testdata/templates",
+			environment: "prod",
+			want: []template.JobTemplateConfig{
+				{
+					Name: "etl", ReleaseLabel: "emr-6.4.0-latest", LogGroupName: "etl-prod", Tags: map[string]string{"Environment": "prod"},
+					SourceFile: "testdata/templates/etl.yaml", SourceLine: 4,
+				},
+				{
+					Name: "reports", LogGroupName: "reports", Tags: map[string]string{"Environment": "prod"},
+					SourceFile: "testdata/templates/reports.yml", SourceLine: 2,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := template.LoadConfig(tt.path, template.WithEnvironment(tt.environment))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.JobTemplates)
+		})
+	}
+}
+
+func TestLoadConfig_MultipleFileErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := template.LoadConfig("testdata/duplicates")
+	var configErrors template.ConfigErrors
+	require.ErrorAs(t, err, &configErrors)
+	require.EqualError(t, configErrors, `testdata/duplicates/second.yaml:3: duplicate job template name "etl", also defined at testdata/duplicates/first.yaml:2`)
+
+	_, err = template.LoadConfig("testdata/templates/*.json")
+	require.EqualError(t, err, "no configuration files found in testdata/templates/*.json")
+
+	_, err = template.LoadConfig("testdata/templates", template.WithEnvironment("qa"))
+	require.EqualError(t, err, `unknown environment "qa": no configuration file in testdata/templates has an overlay for it`)
+}
+
+func TestJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	data, err := template.JSONSchema()
+	require.NoError(t, err)
+
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Defs       map[string]struct {
+			Properties           map[string]map[string]any `json:"properties"`
+			AdditionalProperties bool                      `json:"additionalProperties"`
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	keys := make([]string, 0, len(schema.Properties))
+	for key := range schema.Properties {
+		keys = append(keys, key)
+	}
+	assert.ElementsMatch(t, []string{"defaults", "base_templates", "job_templates", "environments"}, keys)
+	assert.JSONEq(t, `{"type": "array", "items": {"$ref": "#/$defs/JobTemplateConfig", "required": ["name"]}}`, string(schema.Properties["job_templates"]))
+
+	jobTemplate := schema.Defs["JobTemplateConfig"]
+	assert.False(t, jobTemplate.AdditionalProperties)
+	assert.NotContains(t, jobTemplate.Properties, "SourceFile")
+	assert.Equal(t, []any{"ENABLED", "DISABLED"}, jobTemplate.Properties["persistent_app_ui"]["enum"])
+	assert.Equal(t, []any{
+		map[string]any{"type": "string"},
+		map[string]any{"$ref": "#/$defs/SSMParameter"},
+	}, jobTemplate.Properties["ssm_parameters"]["items"].(map[string]any)["anyOf"])
+	assert.Equal(t, "string", schema.Defs["SmokeTestConfig"].Properties["timeout"]["type"])
+	assert.Contains(t, schema.Defs, "EnvironmentOverlay")
+}
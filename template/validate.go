@@ -0,0 +1,320 @@
+package template
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/emrcontainers/types"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+var (
+	executionRoleArnPattern = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:role/[\w+=,.@/-]+$`)
+	releaseLabelPattern     = regexp.MustCompile(`^emr-\d+\.\d+\.\d+(-[a-z0-9-]+)?$`)
+	outputKeyPattern        = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// entryPointSchemes lists the URI schemes Spark on EMR on EKS can load an entry point from.
+var entryPointSchemes = map[string]bool{"s3": true, "s3a": true, "s3n": true, "local": true, "file": true}
+
+// parameterSample stands in for declared parameters when checking the shape of an entry point.
+const parameterSample = "param"
+
+// persistentAppUIValues lists the allowed persistent_app_ui values.
+var persistentAppUIValues = map[string]bool{"ENABLED": true, "DISABLED": true}
+
+// ValidationError describes a semantic problem in a job template configuration.
+// Warnings are reported but do not make the configuration invalid. File and Line locate
+// the job template when it was loaded from a file.
+type ValidationError struct {
+	Template string
+	Field    string
+	Message  string
+	Warning  bool
+	File     string
+	Line     int
+}
+
+func (e ValidationError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d: job template %q: %s: %s", e.File, e.Line, e.Template, e.Field, e.Message)
+	}
+
+	return fmt.Sprintf("job template %q: %s: %s", e.Template, e.Field, e.Message)
+}
+
+// CountErrors returns the number of validation problems that are not warnings.
+func CountErrors(validationErrors []ValidationError) int {
+	count := 0
+	for _, validationError := range validationErrors {
+		if !validationError.Warning {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Validate checks every job template and returns all problems found, in configuration order.
+func Validate(config *Config) []ValidationError {
+	var validationErrors []ValidationError
+
+	names := make(map[string]int)
+	pmNames := make(map[string]string)
+	for i, jobTemplate := range config.JobTemplates {
+		name := jobTemplate.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		addProblem := func(warning bool, field, format string, args ...any) {
+			validationErrors = append(validationErrors, ValidationError{
+				Template: name, Field: field, Message: fmt.Sprintf(format, args...), Warning: warning,
+				File: jobTemplate.SourceFile, Line: jobTemplate.SourceLine,
+			})
+		}
+		addError := func(field, format string, args ...any) {
+			addProblem(false, field, format, args...)
+		}
+		addWarning := func(field, format string, args ...any) {
+			addProblem(true, field, format, args...)
+		}
+
+		if jobTemplate.Name == "" {
+			addError("name", "must be set")
+		} else if first, ok := names[jobTemplate.Name]; ok {
+			addError("name", "duplicate name, also used by job template #%d", first+1)
+		} else {
+			names[jobTemplate.Name] = i
+		}
+
+		validateJobTemplate(jobTemplate, addError)
+		validatePlaceholders(jobTemplate, addError, addWarning)
+
+		for j, param := range jobTemplate.SSMParameters {
+			pmName := param.Name
+			if pmName == "" {
+				addError("ssm_parameters", "must not contain empty names")
+			} else if owner, ok := pmNames[pmName]; ok && owner != name {
+				addError("ssm_parameters", "parameter %q is already mapped to job template %q", pmName, owner)
+			} else {
+				pmNames[pmName] = name
+			}
+			validateSSMParameter(param, fmt.Sprintf("ssm_parameters[%d]", j), addError)
+		}
+
+		for j, output := range jobTemplate.Outputs {
+			validateOutput(output, fmt.Sprintf("outputs[%d]", j), addError)
+		}
+
+		if jobTemplate.SmokeTest != nil {
+			validateSmokeTest(*jobTemplate.SmokeTest, jobTemplate.ParameterConfiguration, addError)
+		}
+	}
+
+	return validationErrors
+}
+
+// validateJobTemplate checks the fields of a single job template.
+func validateJobTemplate(jobTemplate JobTemplateConfig, addError func(field, format string, args ...any)) {
+	validateFormats(jobTemplate, addError)
+
+	if !persistentAppUIValues[jobTemplate.PersistentAppUI] {
+		addError("persistent_app_ui", "%q must be ENABLED or DISABLED", jobTemplate.PersistentAppUI)
+	}
+
+	if jobTemplate.LogGroupName == "" {
+		addError("log_group_name", "must be set")
+	}
+
+	validateSparkSubmitParameters(jobTemplate.SparkSubmitParameters, addError)
+
+	for _, paramName := range sortedKeys(jobTemplate.ParameterConfiguration) {
+		param := jobTemplate.ParameterConfiguration[paramName]
+		field := "parameter_configuration." + paramName
+		switch param.Type {
+		case types.TemplateParameterDataTypeString:
+		case types.TemplateParameterDataTypeNumber:
+			if param.DefaultValue != nil {
+				if _, err := strconv.ParseFloat(*param.DefaultValue, 64); err != nil {
+					addError(field+".default_value", "%q is not a number", *param.DefaultValue)
+				}
+			}
+		default:
+			addError(field+".type", "%q must be STRING or NUMBER", param.Type)
+		}
+	}
+}
+
+// validateSparkSubmitParameters checks that the required Spark submit parameters are set and
+// that no conf or extra argument is empty.
+func validateSparkSubmitParameters(spark SparkSubmitParameters, addError func(field, format string, args ...any)) {
+	requiredSpark := []struct{ field, value string }{
+		{"master", spark.Master}, {"deploy_mode", spark.DeployMode}, {"class", spark.Class}, {"packages", spark.Packages},
+	}
+	for _, required := range requiredSpark {
+		if required.value == "" {
+			addError("spark_submit_parameters."+required.field, "must be set")
+		}
+	}
+	for i, conf := range spark.Conf {
+		if conf == "" {
+			addError(fmt.Sprintf("spark_submit_parameters.conf[%d]", i), "must not be empty")
+		}
+	}
+	for i, arg := range spark.ExtraArgs {
+		if arg == "" {
+			addError(fmt.Sprintf("spark_submit_parameters.extra_args[%d]", i), "must not be empty")
+		}
+	}
+}
+
+// validateSSMParameter checks the metadata of an SSM parameter.
+func validateSSMParameter(param SSMParameter, field string, addError func(field, format string, args ...any)) {
+	switch ssmtypes.ParameterType(param.Type) {
+	case "", ssmtypes.ParameterTypeString:
+		if param.KeyID != "" {
+			addError(field+".key_id", "is only used with type SecureString")
+		}
+	case ssmtypes.ParameterTypeSecureString:
+	default:
+		addError(field+".type", "%q must be String or SecureString", param.Type)
+	}
+
+	switch ssmtypes.ParameterTier(param.Tier) {
+	case "", ssmtypes.ParameterTierStandard, ssmtypes.ParameterTierAdvanced, ssmtypes.ParameterTierIntelligentTiering:
+	default:
+		addError(field+".tier", "%q must be Standard, Advanced or Intelligent-Tiering", param.Tier)
+	}
+
+	for _, key := range sortedKeys(param.Tags) {
+		if key == "" {
+			addError(field+".tags", "must not contain empty keys")
+		}
+	}
+}
+
+// validateOutput checks an additional job template ID destination.
+func validateOutput(output OutputConfig, field string, addError func(field, format string, args ...any)) {
+	switch output.Type {
+	case OutputTypeManifest:
+		if output.Format != "" && output.Format != "json" && output.Format != "yaml" {
+			addError(field+".format", "%q must be json or yaml", output.Format)
+		}
+	case OutputTypeDotenv:
+		if output.Key != "" && !outputKeyPattern.MatchString(output.Key) {
+			addError(field+".key", "%q is not a valid variable name", output.Key)
+		}
+	case OutputTypeStdout:
+		if output.Path != "" || output.PathEnv != "" {
+			addError(field, "stdout output does not take a path")
+		}
+
+		return
+	default:
+		addError(field+".type", "%q must be manifest, dotenv or stdout", output.Type)
+
+		return
+	}
+
+	if (output.Path == "") == (output.PathEnv == "") {
+		addError(field, "exactly one of path and path_env must be set")
+	}
+}
+
+// validateSmokeTest checks that the smoke test only overrides declared parameters.
+func validateSmokeTest(smokeTest SmokeTestConfig, paramConfig map[string]TemplateParameterConfiguration, addError func(field, format string, args ...any)) {
+	for _, name := range sortedKeys(smokeTest.Parameters) {
+		param, ok := paramConfig[name]
+		if !ok {
+			addError("smoke_test.parameters."+name, "is not declared in parameter_configuration")
+
+			continue
+		}
+		if param.Type == types.TemplateParameterDataTypeNumber {
+			if _, err := strconv.ParseFloat(smokeTest.Parameters[name], 64); err != nil {
+				addError("smoke_test.parameters."+name, "%q is not a number", smokeTest.Parameters[name])
+			}
+		}
+	}
+
+	if smokeTest.Timeout < 0 {
+		addError("smoke_test.timeout", "must not be negative")
+	}
+}
+
+// validateFormats checks the execution role ARN, the release label and the entry point. Values
+// referencing declared parameters are only known when the job runs: the role ARN and release
+// label are then not checked, and the entry point is checked with the parameters substituted.
+func validateFormats(jobTemplate JobTemplateConfig, addError func(field, format string, args ...any)) {
+	paramConfig := jobTemplate.ParameterConfiguration
+
+	if _, parameterized := substituteParameters(jobTemplate.ExecutionRoleArn, paramConfig); !parameterized && !executionRoleArnPattern.MatchString(jobTemplate.ExecutionRoleArn) {
+		addError("execution_role_arn", "%q is not an IAM role ARN", jobTemplate.ExecutionRoleArn)
+	}
+
+	if _, parameterized := substituteParameters(jobTemplate.ReleaseLabel, paramConfig); !parameterized && !releaseLabelPattern.MatchString(jobTemplate.ReleaseLabel) {
+		addError("release_label", "%q is not a release label such as emr-6.4.0-latest", jobTemplate.ReleaseLabel)
+	}
+
+	if err := validateEntryPoint(jobTemplate.EntryPoint, paramConfig); err != nil {
+		addError("entry_point", "%v", err)
+	}
+}
+
+// substituteParameters replaces the placeholders of declared parameters by parameterSample and
+// reports whether the value referenced any.
+func substituteParameters(value string, paramConfig map[string]TemplateParameterConfiguration) (string, bool) {
+	parameterized := false
+	substituted := placeholderPattern.ReplaceAllStringFunc(value, func(placeholder string) string {
+		if _, ok := paramConfig[placeholderPattern.FindStringSubmatch(placeholder)[1]]; !ok {
+			return placeholder
+		}
+		parameterized = true
+
+		return parameterSample
+	})
+
+	return substituted, parameterized
+}
+
+// validateEntryPoint checks that the entry point is an S3 or local URI, with declared parameters
+// substituted. An entry point that is a single parameter is not checked.
+func validateEntryPoint(entryPoint string, paramConfig map[string]TemplateParameterConfiguration) error {
+	substituted, parameterized := substituteParameters(entryPoint, paramConfig)
+	if parameterized && substituted == parameterSample {
+		return nil
+	}
+
+	parsed, err := url.Parse(substituted)
+	if err != nil {
+		return fmt.Errorf("%q is not a URI: %w", entryPoint, err)
+	}
+
+	if !entryPointSchemes[parsed.Scheme] {
+		return fmt.Errorf("%q must be an s3:// or local:// URI", entryPoint)
+	}
+
+	if (parsed.Scheme == "s3" || parsed.Scheme == "s3a" || parsed.Scheme == "s3n") && (parsed.Host == "" || parsed.Path == "" || parsed.Path == "/") {
+		return fmt.Errorf("%q must name a bucket and a key", entryPoint)
+	}
+
+	if parsed.Host == "" && parsed.Path == "" {
+		return fmt.Errorf("%q has no path", entryPoint)
+	}
+
+	return nil
+}
+
+// sortedKeys returns the keys of a map in lexical order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
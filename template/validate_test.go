@@ -0,0 +1,276 @@
+package template_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/emr-containers-template/template"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validJobTemplate(name string) template.JobTemplateConfig {
+	return template.JobTemplateConfig{
+		Name:             name,
+		ExecutionRoleArn: "arn:aws:iam::123456789012:role/CustomRole",
+		ReleaseLabel:     "emr-6.4.0-latest",
+		EntryPoint:       "s3://bucket/path/to/script.py",
+		PersistentAppUI:  "DISABLED",
+		LogGroupName:     "my-log-group",
+		SparkSubmitParameters: template.SparkSubmitParameters{
+			Master:     "yarn",
+			DeployMode: "cluster",
+			Class:      "org.example.ClassName",
+			Conf:       []string{"spark.dynamicAllocation.maxExecutors=${MaxExecutors}"},
+			Packages:   "org.reactivestreams:reactive-streams:1.0.4",
+		},
+		ParameterConfiguration: map[string]template.TemplateParameterConfiguration{
+			"MaxExecutors": {DefaultValue: aws.String("10"), Type: "NUMBER"},
+		},
+		SSMParameters: []template.SSMParameter{{Name: "/emr/" + name}},
+	}
+}
+
+func TestValidate_ValidConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := template.LoadConfig("testdata/valid_config.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, []template.ValidationError{
+		{
+			Template: "custom-job", Field: "parameter_configuration.ConfigLocation", Message: "parameter is declared but never referenced", Warning: true,
+			File: "testdata/valid_config.yaml", Line: 2,
+		},
+	}, template.Validate(config))
+}
+
+func TestValidate_Errors(t *testing.T) {
+	t.Parallel()
+	invalid := validJobTemplate("invalid")
+	invalid.ExecutionRoleArn = "arn:aws:iam::123:user/CustomRole"
+	invalid.ReleaseLabel = "6.4.0"
+	invalid.EntryPoint = "https://bucket/script.py"
+	invalid.PersistentAppUI = "enabled"
+	invalid.SparkSubmitParameters.Class = ""
+	invalid.SparkSubmitParameters.Conf = []string{""}
+	invalid.SparkSubmitParameters.ExtraArgs = []string{"--verbose", ""}
+	invalid.ParameterConfiguration = map[string]template.TemplateParameterConfiguration{
+		"MaxExecutors": {DefaultValue: aws.String("ten"), Type: "NUMBER"},
+		"Mode":         {DefaultValue: aws.String("fast"), Type: "BOOLEAN"},
+	}
+	invalid.SSMParameters = []template.SSMParameter{{Name: "/emr/valid"}}
+
+	config := &template.Config{
+		JobTemplates: []template.JobTemplateConfig{validJobTemplate("valid"), invalid, validJobTemplate("valid")},
+	}
+
+	got := template.Validate(config)
+
+	assert.Equal(t, []template.ValidationError{
+		{Template: "invalid", Field: "execution_role_arn", Message: `"arn:aws:iam::123:user/CustomRole" is not an IAM role ARN`},
+		{Template: "invalid", Field: "release_label", Message: `"6.4.0" is not a release label such as emr-6.4.0-latest`},
+		{Template: "invalid", Field: "entry_point", Message: `"https://bucket/script.py" must be an s3:// or local:// URI`},
+		{Template: "invalid", Field: "persistent_app_ui", Message: `"enabled" must be ENABLED or DISABLED`},
+		{Template: "invalid", Field: "spark_submit_parameters.class", Message: "must be set"},
+		{Template: "invalid", Field: "spark_submit_parameters.conf[0]", Message: "must not be empty"},
+		{Template: "invalid", Field: "spark_submit_parameters.extra_args[1]", Message: "must not be empty"},
+		{Template: "invalid", Field: "parameter_configuration.MaxExecutors.default_value", Message: `"ten" is not a number`},
+		{Template: "invalid", Field: "parameter_configuration.Mode.type", Message: `"BOOLEAN" must be STRING or NUMBER`},
+		{Template: "invalid", Field: "parameter_configuration.MaxExecutors", Message: "parameter is declared but never referenced", Warning: true},
+		{Template: "invalid", Field: "parameter_configuration.Mode", Message: "parameter is declared but never referenced", Warning: true},
+		{Template: "invalid", Field: "ssm_parameters", Message: `parameter "/emr/valid" is already mapped to job template "valid"`},
+		{Template: "valid", Field: "name", Message: "duplicate name, also used by job template #1"},
+	}, got)
+}
+
+func TestValidate_EntryPoints(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		entryPoint string
+		wantErr    bool
+	}{
+		{entryPoint: "s3://bucket/script.py"},
+		{entryPoint: "local:///usr/lib/spark/examples/jars/spark-examples.jar"},
+		{entryPoint: "s3://bucket", wantErr: true},
+		{entryPoint: "script.py", wantErr: true},
+		{entryPoint: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.entryPoint, func(t *testing.T) {
+			t.Parallel()
+			jobTemplate := validJobTemplate("job")
+			jobTemplate.EntryPoint = tt.entryPoint
+
+			got := template.Validate(&template.Config{JobTemplates: []template.JobTemplateConfig{jobTemplate}})
+
+			assert.Equal(t, tt.wantErr, len(got) > 0, got)
+		})
+	}
+}
+
+func TestValidate_ParameterizedFormats(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		modify func(jobTemplate *template.JobTemplateConfig)
+		want   []template.ValidationError
+	}{
+		{
+			name:   "Execution Role Arn",
+			modify: func(jobTemplate *template.JobTemplateConfig) { jobTemplate.ExecutionRoleArn = "${Role}" },
+		},
+		{
+			name:   "Release Label",
+			modify: func(jobTemplate *template.JobTemplateConfig) { jobTemplate.ReleaseLabel = "${Rel}" },
+		},
+		{
+			name: "Entry Point In Bucket",
+			modify: func(jobTemplate *template.JobTemplateConfig) {
+				jobTemplate.EntryPoint = "s3://${S3Bucket}/wordcount.py"
+			},
+		},
+		{
+			name:   "Whole Entry Point",
+			modify: func(jobTemplate *template.JobTemplateConfig) { jobTemplate.EntryPoint = "${Script}" },
+		},
+		{
+			name: "Entry Point Still Checked",
+			modify: func(jobTemplate *template.JobTemplateConfig) {
+				jobTemplate.EntryPoint = "https://${S3Bucket}/wordcount.py"
+			},
+			want: []template.ValidationError{
+				{Template: "job", Field: "entry_point", Message: `"https://${S3Bucket}/wordcount.py" must be an s3:// or local:// URI`},
+			},
+		},
+		{
+			name:   "Undeclared Parameter",
+			modify: func(jobTemplate *template.JobTemplateConfig) { jobTemplate.ReleaseLabel = "${Undeclared}" },
+			want: []template.ValidationError{
+				{Template: "job", Field: "release_label", Message: `"${Undeclared}" is not a release label such as emr-6.4.0-latest`},
+				{Template: "job", Field: "release_label", Message: "placeholder ${Undeclared} is not declared in parameter_configuration"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			jobTemplate := validJobTemplate("job")
+			jobTemplate.EntryPointArguments = []string{"${Role}", "${Rel}", "${S3Bucket}", "${Script}"}
+			for _, key := range []string{"Role", "Rel", "S3Bucket", "Script"} {
+				jobTemplate.ParameterConfiguration[key] = template.TemplateParameterConfiguration{Type: "STRING"}
+			}
+			tt.modify(&jobTemplate)
+
+			got := template.Validate(&template.Config{JobTemplates: []template.JobTemplateConfig{jobTemplate}})
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidate_Placeholders(t *testing.T) {
+	t.Parallel()
+	jobTemplate := validJobTemplate("placeholders")
+	jobTemplate.EntryPointArguments = []string{"--limit", "${MaxExecutors}", "--date=${RunDate}"}
+	jobTemplate.ApplicationConfigurations = []template.ApplicationConfiguration{
+		{Classification: "spark-defaults", Properties: map[string]string{"spark.app.name": "${AppName}", "spark.home": "${env:SPARK_HOME}"}},
+	}
+
+	got := template.Validate(&template.Config{JobTemplates: []template.JobTemplateConfig{jobTemplate}})
+
+	assert.Equal(t, []template.ValidationError{
+		{Template: "placeholders", Field: "application_configurations[0].properties.spark.app.name", Message: "placeholder ${AppName} is not declared in parameter_configuration"},
+		{Template: "placeholders", Field: "entry_point_arguments[2]", Message: "placeholder ${RunDate} is not declared in parameter_configuration"},
+	}, got)
+}
+
+func TestValidate_InheritedParameters(t *testing.T) {
+	t.Parallel()
+
+	config, err := template.LoadConfig("testdata/inherited_parameters.yaml")
+	require.NoError(t, err)
+
+	// Parameters from defaults and base templates may go unused, the entry's own declarations may not.
+	assert.Equal(t, []template.ValidationError{
+		{
+			Template: "own-unused", Field: "parameter_configuration.Unused", Message: "parameter is declared but never referenced", Warning: true,
+			File: "testdata/inherited_parameters.yaml", Line: 26,
+		},
+	}, template.Validate(config))
+}
+
+func TestPlaceholders(t *testing.T) {
+	t.Parallel()
+	jobTemplate := validJobTemplate("placeholders")
+	jobTemplate.EntryPoint = "s3://bucket/${Version}/script.py"
+	jobTemplate.EntryPointArguments = []string{"${Version}-${MaxExecutors}"}
+	jobTemplate.Tags = map[string]string{"Version": "${Version}"}
+	jobTemplate.SSMParameters = []template.SSMParameter{{Name: "/emr/${Ignored}"}}
+
+	assert.Equal(t, map[string][]string{
+		"MaxExecutors": {"entry_point_arguments[0]", "spark_submit_parameters.conf[0]"},
+		"Version":      {"entry_point", "entry_point_arguments[0]", "tags.Version"},
+	}, template.Placeholders(jobTemplate))
+}
+
+func TestValidate_SSMParameterSpecs(t *testing.T) {
+	t.Parallel()
+	jobTemplate := validJobTemplate("specs")
+	jobTemplate.SSMParameters = []template.SSMParameter{
+		{Name: "/emr/secure", Type: "SecureString", KeyID: "alias/emr", Tier: "Intelligent-Tiering"},
+		{Name: "/emr/plain", KeyID: "alias/emr", Tier: "Premium"},
+		{Name: "/emr/list", Type: "StringList", Tags: map[string]string{"": "x"}},
+	}
+
+	got := template.Validate(&template.Config{JobTemplates: []template.JobTemplateConfig{jobTemplate}})
+
+	assert.Equal(t, []template.ValidationError{
+		{Template: "specs", Field: "ssm_parameters[1].key_id", Message: "is only used with type SecureString"},
+		{Template: "specs", Field: "ssm_parameters[1].tier", Message: `"Premium" must be Standard, Advanced or Intelligent-Tiering`},
+		{Template: "specs", Field: "ssm_parameters[2].type", Message: `"StringList" must be String or SecureString`},
+		{Template: "specs", Field: "ssm_parameters[2].tags", Message: "must not contain empty keys"},
+	}, got)
+}
+
+func TestValidate_Outputs(t *testing.T) {
+	t.Parallel()
+	jobTemplate := validJobTemplate("outputs")
+	jobTemplate.Outputs = []template.OutputConfig{
+		{Type: "manifest", Path: "templates.json"},
+		{Type: "dotenv", PathEnv: "GITHUB_OUTPUT", Key: "JOB_ID"},
+		{Type: "stdout"},
+		{Type: "manifest", Path: "templates.toml", Format: "toml"},
+		{Type: "dotenv", Key: "job-id"},
+		{Type: "stdout", Path: "out.txt"},
+		{Type: "secretsmanager"},
+	}
+
+	got := template.Validate(&template.Config{JobTemplates: []template.JobTemplateConfig{jobTemplate}})
+
+	assert.Equal(t, []template.ValidationError{
+		{Template: "outputs", Field: "outputs[3].format", Message: `"toml" must be json or yaml`},
+		{Template: "outputs", Field: "outputs[4].key", Message: `"job-id" is not a valid variable name`},
+		{Template: "outputs", Field: "outputs[4]", Message: "exactly one of path and path_env must be set"},
+		{Template: "outputs", Field: "outputs[5]", Message: "stdout output does not take a path"},
+		{Template: "outputs", Field: "outputs[6].type", Message: `"secretsmanager" must be manifest, dotenv or stdout`},
+	}, got)
+}
+
+func TestValidate_SmokeTest(t *testing.T) {
+	t.Parallel()
+	jobTemplate := validJobTemplate("smoke")
+	jobTemplate.SmokeTest = &template.SmokeTestConfig{
+		Parameters: map[string]string{"MaxExecutors": "many", "RunDate": "2024-01-01"},
+		Timeout:    -time.Minute,
+	}
+
+	got := template.Validate(&template.Config{JobTemplates: []template.JobTemplateConfig{jobTemplate}})
+
+	assert.Equal(t, []template.ValidationError{
+		{Template: "smoke", Field: "smoke_test.parameters.MaxExecutors", Message: `"many" is not a number`},
+		{Template: "smoke", Field: "smoke_test.parameters.RunDate", Message: "is not declared in parameter_configuration"},
+		{Template: "smoke", Field: "smoke_test.timeout", Message: "must not be negative"},
+	}, got)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoGstickGo/emr-containers-template/template"
+)
+
+// loadJobTemplates loads the YAML configuration with the overlay of the selected environment, maps the SSM parameters and validates every job template.
+// Validation problems are returned separately so that callers can report all of them together.
+func loadJobTemplates(logger *logrus.Logger, cfg Config) (*template.Config, []template.ValidationError, error) {
+	jobConfigs, err := template.LoadConfig(cfg.PathYAML, template.WithEnvironment(cfg.Environment))
+	if err != nil {
+
+		return nil, nil, err
+	}
+	if cfg.Environment != "" {
+		logger.Infof("Loaded %d job templates from configuration for environment %s", len(jobConfigs.JobTemplates), cfg.Environment)
+	} else {
+		logger.Infof("Loaded %d job templates from configuration", len(jobConfigs.JobTemplates))
+	}
+
+	if err := resolveSSMParameters(jobConfigs.JobTemplates, cfg.PmNames); err != nil {
+
+		return nil, nil, fmt.Errorf("%w. Please review your job template configurations and corresponding SSM parameters", err)
+	}
+
+	return jobConfigs, template.Validate(jobConfigs), nil
+}
+
+// writeValidation prints the validation result and reports whether the configuration is valid.
+// Warnings are printed but do not make the configuration invalid.
+func writeValidation(w io.Writer, jobConfigs *template.Config, validationErrors []template.ValidationError) bool {
+	for _, validationError := range validationErrors {
+		if validationError.Warning {
+			fmt.Fprintf(w, "warning: %s\n", validationError)
+		} else {
+			fmt.Fprintf(w, "error: %s\n", validationError)
+		}
+	}
+
+	if errorCount := template.CountErrors(validationErrors); errorCount > 0 {
+		fmt.Fprintf(w, "%d errors found in %d job templates\n", errorCount, len(jobConfigs.JobTemplates))
+
+		return false
+	}
+	fmt.Fprintf(w, "%d job templates are valid\n", len(jobConfigs.JobTemplates))
+
+	return true
+}